@@ -33,8 +33,11 @@ type addSyncCmdFlags struct {
 }
 
 type addPortCmdFlags struct {
+	Name         string
 	ResourceType string
+	ResourceName string
 	Selector     string
+	Reverse      bool
 }
 
 func init() {
@@ -99,16 +102,20 @@ func init() {
 	################ devspace add port ####################
 	#######################################################
 	Add a new port mapping that should be forwarded to
-	the devspace (format is local:remote comma separated):
-	devspace add port 8080:80,3000
+	the devspace (format is local:remote comma separated,
+	local can be auto to pick a free port):
+	devspace add port 8080:80,3000,auto:9090
 	#######################################################
 	`,
 		Args: cobra.ExactArgs(1),
 		Run:  cmd.RunAddPort,
 	}
 
-	addPortCmd.Flags().StringVar(&cmd.portFlags.ResourceType, "resource-type", "pod", "Selected resource type")
+	addPortCmd.Flags().StringVar(&cmd.portFlags.ResourceType, "resource-type", "pod", "Selected resource type (pod, service, deployment or statefulset)")
+	addPortCmd.Flags().StringVar(&cmd.portFlags.ResourceName, "resource-name", "", "Name of the selected resource (instead of a selector)")
 	addPortCmd.Flags().StringVar(&cmd.portFlags.Selector, "selector", "", "Comma separated key=value selector list (e.g. release=test)")
+	addPortCmd.Flags().StringVar(&cmd.portFlags.Name, "name", "", "Name of the port forwarding")
+	addPortCmd.Flags().BoolVar(&cmd.portFlags.Reverse, "reverse", false, "Make the local ports available inside the container instead")
 
 	addCmd.AddCommand(addPortCmd)
 }
@@ -171,7 +178,7 @@ func (cmd *AddCmd) RunAddSync(cobraCmd *cobra.Command, args []string) {
 func (cmd *AddCmd) RunAddPort(cobraCmd *cobra.Command, args []string) {
 	config := configutil.GetConfig(false)
 
-	if cmd.portFlags.Selector == "" {
+	if cmd.portFlags.Selector == "" && cmd.portFlags.ResourceName == "" {
 		cmd.portFlags.Selector = "release=" + *config.DevSpace.Release.Name
 	}
 
@@ -209,23 +216,59 @@ func (cmd *AddCmd) insertOrReplacePortMapping(labelSelectorMap map[string]*strin
 			selectors = map[string]*string{}
 		}
 
-		if *v.ResourceType == cmd.portFlags.ResourceType && isMapEqual(selectors, labelSelectorMap) {
-			portMap := append(*v.PortMappings, portMappings...)
+		resourceName := ""
+		if v.ResourceName != nil {
+			resourceName = *v.ResourceName
+		}
+
+		name := ""
+		if v.Name != nil {
+			name = *v.Name
+		}
 
-			v.PortMappings = &portMap
+		if getPortForwardingResourceType(v) == cmd.portFlags.ResourceType && resourceName == cmd.portFlags.ResourceName && name == cmd.portFlags.Name && isMapEqual(selectors, labelSelectorMap) {
+			if cmd.portFlags.Reverse {
+				v.ReversePortMappings = appendPortMappings(v.ReversePortMappings, portMappings)
+			} else {
+				v.PortMappings = appendPortMappings(v.PortMappings, portMappings)
+			}
 
 			return
 		}
 	}
-	portMap := append(*config.DevSpace.PortForwarding, &v1.PortForwardingConfig{
+	portForwarding := &v1.PortForwardingConfig{
 		ResourceType:  configutil.String(cmd.portFlags.ResourceType),
 		LabelSelector: &labelSelectorMap,
-		PortMappings:  &portMappings,
-	})
+	}
+
+	if cmd.portFlags.Reverse {
+		portForwarding.ReversePortMappings = &portMappings
+	} else {
+		portForwarding.PortMappings = &portMappings
+	}
+
+	if cmd.portFlags.ResourceName != "" {
+		portForwarding.ResourceName = configutil.String(cmd.portFlags.ResourceName)
+	}
+
+	if cmd.portFlags.Name != "" {
+		portForwarding.Name = configutil.String(cmd.portFlags.Name)
+	}
+
+	portMap := append(*config.DevSpace.PortForwarding, portForwarding)
 
 	config.DevSpace.PortForwarding = &portMap
 }
 
+func appendPortMappings(existing *[]*v1.PortMapping, portMappings []*v1.PortMapping) *[]*v1.PortMapping {
+	if existing == nil {
+		return &portMappings
+	}
+
+	portMap := append(*existing, portMappings...)
+	return &portMap
+}
+
 func isMapEqual(map1 map[string]*string, map2 map[string]*string) bool {
 	if len(map1) != len(map2) {
 		return false
@@ -252,18 +295,29 @@ func parsePortMappings(portMappingsString string) ([]*v1.PortMapping, error) {
 		}
 
 		portMappingStruct := &v1.PortMapping{}
-		firstPort, err := strconv.Atoi(portMapping[0])
-
-		if err != nil {
-			return nil, err
-		}
 
 		if len(portMapping) == 1 {
-			portMappingStruct.LocalPort = &firstPort
+			firstPort, err := strconv.Atoi(portMapping[0])
 
-			portMappingStruct.RemotePort = portMappingStruct.LocalPort
+			if err != nil {
+				return nil, err
+			}
+
+			portMappingStruct.LocalPort = v1.NewLocalPort(firstPort)
+			portMappingStruct.RemotePort = &firstPort
 		} else {
-			portMappingStruct.LocalPort = &firstPort
+			localPort := v1.LocalPort(portMapping[0])
+
+			// The local port can be "auto" to pick a free port
+			if localPort.IsAuto() == false {
+				_, err := localPort.Int()
+
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			portMappingStruct.LocalPort = &localPort
 
 			secondPort, err := strconv.Atoi(portMapping[1])
 
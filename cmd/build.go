@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/covexo/devspace/pkg/devspace/config/configutil"
+	"github.com/covexo/devspace/pkg/devspace/image"
+	"github.com/covexo/devspace/pkg/util/log"
+	"github.com/spf13/cobra"
+)
+
+// BuildCmd holds the required data for the build cmd
+type BuildCmd struct {
+	flags *BuildCmdFlags
+}
+
+// BuildCmdFlags holds the possible build cmd flags
+type BuildCmdFlags struct {
+	images      []string
+	force       bool
+	noPush      bool
+	tag         string
+	output      string
+	parallelism int
+}
+
+// BuildFlagsDefault are the default flags for BuildCmdFlags
+var BuildFlagsDefault = &BuildCmdFlags{
+	images:      []string{},
+	force:       false,
+	noPush:      false,
+	tag:         "",
+	output:      "",
+	parallelism: image.DefaultParallelism,
+}
+
+func init() {
+	cmd := &BuildCmd{
+		flags: BuildFlagsDefault,
+	}
+
+	cobraCmd := &cobra.Command{
+		Use:   "build",
+		Short: "Builds and pushes your images",
+		Long: `
+#######################################################
+################### devspace build ####################
+#######################################################
+Builds all images of the config (if their build context
+has changed) and pushes them to their registries
+without deploying the chart, e.g. in CI pipelines.
+Tags that were pushed for the same build context
+before are reused if they still exist.
+
+Examples:
+devspace build --image default --force
+devspace build --tag v1.0.0 --output json
+#######################################################`,
+		Args: cobra.NoArgs,
+		Run:  cmd.Run,
+	}
+	rootCmd.AddCommand(cobraCmd)
+
+	cobraCmd.Flags().StringSliceVar(&cmd.flags.images, "image", cmd.flags.images, "Build only these images (name in the config)")
+	cobraCmd.Flags().BoolVarP(&cmd.flags.force, "force", "f", cmd.flags.force, "Build the images even if the build context has not been modified or was pushed before")
+	cobraCmd.Flags().BoolVar(&cmd.flags.noPush, "no-push", cmd.flags.noPush, "Build the images without pushing them (docker engine only, the config is not updated)")
+	cobraCmd.Flags().StringVarP(&cmd.flags.tag, "tag", "t", cmd.flags.tag, "Tag for the images instead of the configured tagStrategy (implies --force)")
+	cobraCmd.Flags().StringVarP(&cmd.flags.output, "output", "o", cmd.flags.output, "Output format, 'json' prints the image name, tag, digest and duration of every image to stdout")
+	cobraCmd.Flags().IntVar(&cmd.flags.parallelism, "parallelism", cmd.flags.parallelism, "Maximum number of images that are built at the same time")
+}
+
+// Run executes the build command logic
+func (cmd *BuildCmd) Run(cobraCmd *cobra.Command, args []string) {
+	if cmd.flags.output != "" && cmd.flags.output != "json" {
+		log.Fatalf("Unsupported output format '%s', only 'json' is supported", cmd.flags.output)
+	}
+
+	// Keep stdout free for the json output
+	buildLog := log.GetInstance()
+	if cmd.flags.output == "json" {
+		log.SetOutput(os.Stderr)
+
+		// The docker engine only prints progress bars to stdout for the global logger
+		buildLog = log.NewPrefixLogger("", buildLog)
+	}
+
+	log.StartFileLogging()
+
+	workdir, err := os.Getwd()
+	if err != nil {
+		log.Fatalf("Unable to determine current workdir: %s", err.Error())
+	}
+
+	configExists, _ := configutil.ConfigExists()
+	if !configExists {
+		log.Fatal("Couldn't find a devspace config. Please run `devspace init` first")
+	}
+
+	results, err := image.BuildImages(&image.BuildOptions{
+		Workdir:     workdir,
+		ImageNames:  cmd.flags.images,
+		Force:       cmd.flags.force || cmd.flags.tag != "",
+		NoPush:      cmd.flags.noPush,
+		Tag:         cmd.flags.tag,
+		Parallelism: cmd.flags.parallelism,
+		Log:         buildLog,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if cmd.flags.output == "json" {
+		out, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		fmt.Fprintln(os.Stdout, string(out))
+		return
+	}
+
+	for _, result := range results {
+		if result.Built {
+			log.Donef("Built image '%s' as %s:%s in %.1fs", result.ImageName, result.Name, result.Tag, result.Duration)
+		} else if result.Cached {
+			log.Donef("Reused image '%s' as %s:%s from the build cache", result.ImageName, result.Name, result.Tag)
+		}
+	}
+}
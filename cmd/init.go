@@ -278,6 +278,10 @@ func (cmd *InitCmd) configureDevSpace() {
 
 func (cmd *InitCmd) addPortForwarding(port int) {
 	for _, portForwarding := range *cmd.config.DevSpace.PortForwarding {
+		if portForwarding.PortMappings == nil {
+			continue
+		}
+
 		for _, portMapping := range *portForwarding.PortMappings {
 			if *portMapping.RemotePort == port {
 				return
@@ -288,7 +292,7 @@ func (cmd *InitCmd) addPortForwarding(port int) {
 	portForwarding := append(*cmd.config.DevSpace.PortForwarding, &v1.PortForwardingConfig{
 		PortMappings: &[]*v1.PortMapping{
 			{
-				LocalPort:  &port,
+				LocalPort:  v1.NewLocalPort(port),
 				RemotePort: &port,
 			},
 		},
@@ -431,7 +435,7 @@ func (cmd *InitCmd) configureRegistry() {
 	var imageBuilder *docker.Builder
 	var dockerBuilderErr error
 
-	imageBuilder, dockerBuilderErr = docker.NewBuilder("", "", "", false)
+	imageBuilder, dockerBuilderErr = docker.NewBuilder("", "", "", false, log.GetInstance())
 
 	if dockerBuilderErr == nil {
 		log.StartWait("Checking Docker credentials")
@@ -467,7 +471,7 @@ func (cmd *InitCmd) configureRegistry() {
 		if dockerUsername == "" {
 			if *registryURL != "hub.docker.com" {
 				loginWarningServer = " " + *registryURL
-				imageBuilder, dockerBuilderErr = docker.NewBuilder(*registryURL, "", "", false)
+				imageBuilder, dockerBuilderErr = docker.NewBuilder(*registryURL, "", "", false, log.GetInstance())
 			}
 
 			if dockerBuilderErr == nil {
@@ -4,6 +4,7 @@ import (
 	"strconv"
 
 	"github.com/covexo/devspace/pkg/devspace/config/configutil"
+	"github.com/covexo/devspace/pkg/devspace/config/v1"
 	"github.com/covexo/devspace/pkg/util/log"
 	"github.com/spf13/cobra"
 )
@@ -138,9 +139,11 @@ func (cmd *ListCmd) RunListPort(cobraCmd *cobra.Command, args []string) {
 	}
 
 	headerColumnNames := []string{
+		"Name",
 		"Type",
 		"Selector",
 		"Ports (Local:Remote)",
+		"Reverse Ports (Local:Remote)",
 	}
 
 	portForwards := make([][]string, 0, len(*config.DevSpace.PortForwarding))
@@ -149,30 +152,48 @@ func (cmd *ListCmd) RunListPort(cobraCmd *cobra.Command, args []string) {
 	for _, value := range *config.DevSpace.PortForwarding {
 		selector := ""
 
-		for k, v := range *value.LabelSelector {
-			if len(selector) > 0 {
-				selector += ", "
-			}
-
-			selector += k + "=" + *v
-		}
-
-		portMappings := ""
+		if value.ResourceName != nil && *value.ResourceName != "" {
+			selector = "name=" + *value.ResourceName
+		} else if value.LabelSelector != nil {
+			for k, v := range *value.LabelSelector {
+				if len(selector) > 0 {
+					selector += ", "
+				}
 
-		for _, v := range *value.PortMappings {
-			if len(portMappings) > 0 {
-				portMappings += ", "
+				selector += k + "=" + *v
 			}
-
-			portMappings += strconv.Itoa(*v.LocalPort) + ":" + strconv.Itoa(*v.RemotePort)
 		}
 
 		portForwards = append(portForwards, []string{
-			*value.ResourceType,
+			getPortForwardingName(value),
+			getPortForwardingResourceType(value),
 			selector,
-			portMappings,
+			portMappingsToString(value.PortMappings),
+			portMappingsToString(value.ReversePortMappings),
 		})
 	}
 
 	log.PrintTable(headerColumnNames, portForwards)
 }
+
+func portMappingsToString(portMappings *[]*v1.PortMapping) string {
+	portMappingsString := ""
+
+	if portMappings == nil {
+		return portMappingsString
+	}
+
+	for _, v := range *portMappings {
+		if len(portMappingsString) > 0 {
+			portMappingsString += ", "
+		}
+
+		if v.BindAddress != nil && *v.BindAddress != "" {
+			portMappingsString += *v.BindAddress + ":"
+		}
+
+		portMappingsString += string(*v.LocalPort) + ":" + strconv.Itoa(*v.RemotePort)
+	}
+
+	return portMappingsString
+}
@@ -0,0 +1,447 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/covexo/devspace/pkg/devspace/builder/buildkit"
+	"github.com/covexo/devspace/pkg/devspace/clients/kubectl"
+	"github.com/covexo/devspace/pkg/devspace/config/configutil"
+	"github.com/covexo/devspace/pkg/devspace/image"
+	"github.com/covexo/devspace/pkg/devspace/registry"
+	"github.com/covexo/devspace/pkg/util/log"
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/kubernetes"
+)
+
+// RegistryCmd holds the information needed for the registry command
+type RegistryCmd struct {
+	flags      *RegistryCmdFlags
+	pruneFlags *RegistryPruneCmdFlags
+	kubectl    *kubernetes.Clientset
+	clients    map[string]*registry.Client
+	stopChans  []chan struct{}
+}
+
+// RegistryCmdFlags holds the possible flags for the registry tags and inspect commands
+type RegistryCmdFlags struct {
+	registry string
+}
+
+// RegistryFlagsDefault holds the default flags for the registry tags and inspect commands
+var RegistryFlagsDefault = &RegistryCmdFlags{
+	registry: "",
+}
+
+// RegistryPruneCmdFlags holds the possible flags for the registry prune command
+type RegistryPruneCmdFlags struct {
+	keep   int
+	images []string
+	dryRun bool
+	gc     bool
+}
+
+// RegistryPruneFlagsDefault holds the default flags for the registry prune command
+var RegistryPruneFlagsDefault = &RegistryPruneCmdFlags{
+	keep:   10,
+	images: []string{},
+	dryRun: false,
+	gc:     false,
+}
+
+// pruneTarget is a repository in a registry whose old tags are deleted
+type pruneTarget struct {
+	registryName     string
+	repository       string
+	protectedTags    []string
+	protectedDigests []string
+}
+
+func init() {
+	cmd := &RegistryCmd{
+		flags:      RegistryFlagsDefault,
+		pruneFlags: RegistryPruneFlagsDefault,
+		clients:    map[string]*registry.Client{},
+	}
+
+	registryCmd := &cobra.Command{
+		Use:   "registry",
+		Short: "Manages the images in your registries",
+		Long: `
+#######################################################
+################## devspace registry ##################
+#######################################################
+Shows and manages the images in the registries of
+the config:
+
+* List the repositories of the registries (list)
+* List the tags of an image (tags)
+* Show the manifest of a tag (inspect)
+* Delete old tags (prune)
+#######################################################`,
+		Args: cobra.NoArgs,
+	}
+
+	rootCmd.AddCommand(registryCmd)
+
+	registryListCmd := &cobra.Command{
+		Use:   "list [registry]",
+		Short: "Lists the repositories of your registries",
+		Long: `
+#######################################################
+############### devspace registry list ################
+#######################################################
+Lists the repositories of all registries in the
+config or of the given registry. Registries that
+don't support listing repositories (e.g. Docker Hub)
+are skipped with a warning.
+
+Examples:
+devspace registry list
+devspace registry list internal
+#######################################################`,
+		Args: cobra.MaximumNArgs(1),
+		Run:  cmd.RunList,
+	}
+
+	registryCmd.AddCommand(registryListCmd)
+
+	registryTagsCmd := &cobra.Command{
+		Use:   "tags [image]",
+		Short: "Lists the tags of an image",
+		Long: `
+#######################################################
+############### devspace registry tags ################
+#######################################################
+Lists the tags of an image of the config (or of a
+repository in the registry given by --registry)
+with their digest, size and creation date, from
+newest to oldest.
+
+Examples:
+devspace registry tags default
+devspace registry tags --registry internal user/app
+#######################################################`,
+		Args: cobra.ExactArgs(1),
+		Run:  cmd.RunTags,
+	}
+
+	registryCmd.AddCommand(registryTagsCmd)
+
+	registryTagsCmd.Flags().StringVar(&cmd.flags.registry, "registry", cmd.flags.registry, "Registry of the config, the argument is a repository in this registry instead of an image name")
+
+	registryInspectCmd := &cobra.Command{
+		Use:   "inspect [image[:tag]]",
+		Short: "Shows the manifest of an image",
+		Long: `
+#######################################################
+############## devspace registry inspect ##############
+#######################################################
+Shows the digest, size, layers and creation date of
+a tag of an image of the config (default: the
+deployed tag) or of a repository in the registry
+given by --registry (default: latest).
+
+Examples:
+devspace registry inspect default
+devspace registry inspect default:3f2a8c1
+devspace registry inspect --registry internal user/app
+#######################################################`,
+		Args: cobra.ExactArgs(1),
+		Run:  cmd.RunInspect,
+	}
+
+	registryCmd.AddCommand(registryInspectCmd)
+
+	registryInspectCmd.Flags().StringVar(&cmd.flags.registry, "registry", cmd.flags.registry, "Registry of the config, the argument is a repository in this registry instead of an image name")
+
+	registryPruneCmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Deletes old tags of your images",
+		Long: `
+#######################################################
+############### devspace registry prune ###############
+#######################################################
+Deletes all but the newest tags of every image in the
+config from its registry. The deployed tag is never
+deleted. With --gc, the internal registry frees the
+disk space of the deleted images.
+
+Examples:
+devspace registry prune --keep 5 --dry-run
+devspace registry prune --image default --gc
+#######################################################`,
+		Args: cobra.NoArgs,
+		Run:  cmd.RunPrune,
+	}
+
+	registryCmd.AddCommand(registryPruneCmd)
+
+	registryPruneCmd.Flags().IntVar(&cmd.pruneFlags.keep, "keep", cmd.pruneFlags.keep, "Number of newest tags to keep per image (the deployed tag is always kept)")
+	registryPruneCmd.Flags().StringSliceVar(&cmd.pruneFlags.images, "image", cmd.pruneFlags.images, "Prune only these images (name in the config)")
+	registryPruneCmd.Flags().BoolVar(&cmd.pruneFlags.dryRun, "dry-run", cmd.pruneFlags.dryRun, "Only print the tags that would be deleted")
+	registryPruneCmd.Flags().BoolVar(&cmd.pruneFlags.gc, "gc", cmd.pruneFlags.gc, "Run the garbage collection in the internal registry after deleting the tags")
+}
+
+// RunPrune executes the registry prune command logic
+func (cmd *RegistryCmd) RunPrune(cobraCmd *cobra.Command, args []string) {
+	if cmd.pruneFlags.keep < 0 {
+		log.Fatal("--keep must not be negative")
+	}
+
+	config := configutil.GetConfig(false)
+	if cmd.pruneFlags.gc && config.Services.InternalRegistry == nil {
+		log.Fatal("--gc is only supported for the internal registry, which is not configured")
+	}
+
+	defer cmd.closeClients()
+
+	targets, err := cmd.getPruneTargets()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	failed := 0
+
+	for _, target := range targets {
+		client, err := cmd.getClient(target.registryName)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		log.StartWait("Pruning " + target.repository)
+		result, err := registry.PruneRepository(client, target.repository, cmd.pruneFlags.keep, target.protectedTags, target.protectedDigests, cmd.pruneFlags.dryRun)
+		log.StopWait()
+
+		if result != nil && len(result.Deleted) > 0 {
+			values := [][]string{}
+			for _, tagInfo := range result.Deleted {
+				values = append(values, []string{tagInfo.Tag, formatCreated(tagInfo.Created), formatImageSize(tagInfo.Size)})
+			}
+
+			log.PrintTable([]string{"Tag", "Created", "Size"}, values)
+		}
+
+		if err != nil {
+			log.Errorf("Error pruning %s: %v", target.repository, err)
+			failed++
+			continue
+		}
+
+		if cmd.pruneFlags.dryRun {
+			log.Infof("Would delete %d tags of %s, %d tags are kept", len(result.Deleted), target.repository, len(result.Kept))
+		} else {
+			log.Donef("Deleted %d tags of %s, %d tags are kept", len(result.Deleted), target.repository, len(result.Kept))
+		}
+	}
+
+	if failed > 0 {
+		log.Fatalf("Pruning %d of %d images failed", failed, len(targets))
+	}
+
+	if cmd.pruneFlags.gc && cmd.pruneFlags.dryRun == false {
+		kubectlClient, err := cmd.getKubectl()
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		log.StartWait("Running garbage collection in the internal registry")
+		output, err := registry.GarbageCollectInternalRegistry(kubectlClient, config.Services.InternalRegistry)
+		log.StopWait()
+
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		log.GetFileLogger("registry").Info(output)
+		log.Done("Garbage collection in the internal registry finished (see .devspace/logs/registry.log)")
+	}
+}
+
+// getPruneTargets returns the repositories of the selected images ordered by name. The tag and digest of
+// every image that uses the repository and the BuildKit cache tags are protected. Images that are not pushed by DevSpace are skipped
+func (cmd *RegistryCmd) getPruneTargets() ([]*pruneTarget, error) {
+	config := configutil.GetConfig(false)
+	imageNames, err := getConfigImageNames(cmd.pruneFlags.images)
+	if err != nil {
+		return nil, err
+	}
+
+	targetMap := map[string]*pruneTarget{}
+
+	for _, imageName := range imageNames {
+		imageConf := (*config.Images)[imageName]
+
+		if image.IsBuildDisabled(imageConf) {
+			log.Infof("Skip image '%s', it is not built by DevSpace", imageName)
+			continue
+		}
+		if image.IsLocalImage(imageConf) {
+			log.Infof("Skip image '%s', it is not pushed to a registry", imageName)
+			continue
+		}
+
+		repository := registry.GetImageURL(imageConf, false)
+
+		target, ok := targetMap[repository]
+		if ok == false {
+			target = &pruneTarget{
+				registryName:     *imageConf.Registry,
+				repository:       *imageConf.Name,
+				protectedTags:    []string{},
+				protectedDigests: []string{},
+			}
+
+			targetMap[repository] = target
+		}
+
+		if imageConf.Tag != nil {
+			target.protectedTags = append(target.protectedTags, *imageConf.Tag)
+		}
+		if imageConf.Digest != nil {
+			target.protectedDigests = append(target.protectedDigests, *imageConf.Digest)
+		}
+	}
+
+	// The BuildKit registry cache can be pushed into a pruned repository (by default as tag buildcache)
+	for _, imageConf := range *config.Images {
+		if image.IsBuildDisabled(imageConf) || imageConf.Build.Engine == nil || imageConf.Name == nil || imageConf.Registry == nil {
+			continue
+		}
+
+		cacheRepo := buildkit.GetCacheRepo(registry.GetImageURL(imageConf, false), imageConf.Build.Engine.Buildkit)
+		if index := strings.LastIndex(cacheRepo, ":"); index > strings.LastIndex(cacheRepo, "/") {
+			if target, ok := targetMap[cacheRepo[:index]]; ok {
+				target.protectedTags = append(target.protectedTags, cacheRepo[index+1:])
+			}
+		}
+	}
+
+	repositories := make([]string, 0, len(targetMap))
+	for repository := range targetMap {
+		repositories = append(repositories, repository)
+	}
+
+	sort.Strings(repositories)
+
+	targets := make([]*pruneTarget, 0, len(repositories))
+	for _, repository := range repositories {
+		targets = append(targets, targetMap[repository])
+	}
+
+	return targets, nil
+}
+
+// getClient returns a registry API client for the registry in the config. The internal registry is
+// forwarded to a local port if it has no ingress
+func (cmd *RegistryCmd) getClient(registryName string) (*registry.Client, error) {
+	if client, ok := cmd.clients[registryName]; ok {
+		return client, nil
+	}
+
+	config := configutil.GetConfig(false)
+
+	registryConf, ok := (*config.Registries)[registryName]
+	if ok == false {
+		return nil, fmt.Errorf("Registry '%s' not found in config", registryName)
+	}
+
+	var client *registry.Client
+
+	if registryName == "internal" && config.Services.InternalRegistry != nil {
+		kubectlClient, err := cmd.getKubectl()
+		if err != nil {
+			return nil, err
+		}
+
+		var stopChan chan struct{}
+
+		client, stopChan, err = registry.NewInternalRegistryClient(kubectlClient, config.Services.InternalRegistry, registryConf)
+		if err != nil {
+			return nil, err
+		}
+
+		cmd.stopChans = append(cmd.stopChans, stopChan)
+	} else {
+		client = registry.NewClientFromConfig(registryConf)
+	}
+
+	cmd.clients[registryName] = client
+
+	return client, nil
+}
+
+func (cmd *RegistryCmd) getKubectl() (*kubernetes.Clientset, error) {
+	if cmd.kubectl == nil {
+		kubectlClient, err := kubectl.NewClient()
+		if err != nil {
+			return nil, fmt.Errorf("Unable to create new kubectl client: %v", err)
+		}
+
+		cmd.kubectl = kubectlClient
+	}
+
+	return cmd.kubectl, nil
+}
+
+// closeClients stops the port forwardings to the internal registry
+func (cmd *RegistryCmd) closeClients() {
+	for _, stopChan := range cmd.stopChans {
+		close(stopChan)
+	}
+
+	cmd.stopChans = nil
+}
+
+// getConfigImageNames returns the sorted names of the selected images or of all images in the config
+func getConfigImageNames(selectedNames []string) ([]string, error) {
+	config := configutil.GetConfig(false)
+	imageNames := []string{}
+
+	if len(selectedNames) == 0 {
+		for imageName := range *config.Images {
+			imageNames = append(imageNames, imageName)
+		}
+	} else {
+		for _, imageName := range selectedNames {
+			if _, ok := (*config.Images)[imageName]; ok == false {
+				return nil, fmt.Errorf("Image '%s' not found in config", imageName)
+			}
+
+			imageNames = append(imageNames, imageName)
+		}
+	}
+
+	sort.Strings(imageNames)
+
+	return imageNames, nil
+}
+
+// formatCreated returns the creation date of an image or - if it is unknown
+func formatCreated(created time.Time) string {
+	if created.IsZero() {
+		return "-"
+	}
+
+	return created.Local().Format("2006-01-02 15:04:05")
+}
+
+// formatImageSize returns the size of an image in a human readable format (e.g. 42.7 MB)
+func formatImageSize(size int64) string {
+	units := []string{"B", "KB", "MB", "GB"}
+	value := float64(size)
+	unit := 0
+
+	for value >= 1000 && unit < len(units)-1 {
+		value /= 1000
+		unit++
+	}
+
+	if unit == 0 {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	return strings.TrimSuffix(fmt.Sprintf("%.1f", value), ".0") + " " + units[unit]
+}
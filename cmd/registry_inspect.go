@@ -0,0 +1,214 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/covexo/devspace/pkg/devspace/config/configutil"
+	"github.com/covexo/devspace/pkg/devspace/registry"
+	"github.com/covexo/devspace/pkg/util/log"
+	"github.com/spf13/cobra"
+)
+
+// RunList executes the registry list command logic
+func (cmd *RegistryCmd) RunList(cobraCmd *cobra.Command, args []string) {
+	config := configutil.GetConfig(false)
+	defer cmd.closeClients()
+
+	registryNames := []string{}
+	if len(args) == 1 {
+		registryNames = append(registryNames, args[0])
+	} else {
+		for registryName := range *config.Registries {
+			registryNames = append(registryNames, registryName)
+		}
+
+		sort.Strings(registryNames)
+	}
+
+	// Show which images of the config are pushed to the repositories
+	configImages := map[string][]string{}
+	for imageName, imageConf := range *config.Images {
+		if imageConf.Name != nil && imageConf.Registry != nil {
+			key := *imageConf.Registry + "/" + *imageConf.Name
+			configImages[key] = append(configImages[key], imageName)
+		}
+	}
+
+	values := [][]string{}
+
+	for _, registryName := range registryNames {
+		client, err := cmd.getClient(registryName)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		log.StartWait("Listing repositories of registry '" + registryName + "'")
+		repositories, err := client.Catalog()
+		log.StopWait()
+
+		if err != nil {
+			log.Warnf("Unable to list the repositories of registry '%s': %v", registryName, err)
+			continue
+		}
+
+		for _, repository := range repositories {
+			imageNames := configImages[registryName+"/"+repository]
+			sort.Strings(imageNames)
+
+			values = append(values, []string{registryName, getRegistryURL(registryName), repository, strings.Join(imageNames, ", ")})
+		}
+	}
+
+	if len(values) == 0 {
+		log.Info("No repositories found")
+		return
+	}
+
+	log.PrintTable([]string{"Registry", "URL", "Repository", "Images"}, values)
+}
+
+// RunTags executes the registry tags command logic
+func (cmd *RegistryCmd) RunTags(cobraCmd *cobra.Command, args []string) {
+	defer cmd.closeClients()
+
+	registryName, repository, deployedTag, err := cmd.getRepository(args[0])
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	client, err := cmd.getClient(registryName)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.StartWait("Listing tags of " + repository)
+	tagInfos, err := registry.GetTagInfos(client, repository)
+	log.StopWait()
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if len(tagInfos) == 0 {
+		log.Infof("No tags found for %s", repository)
+		return
+	}
+
+	values := [][]string{}
+	for _, tagInfo := range tagInfos {
+		deployed := ""
+		if tagInfo.Tag == deployedTag {
+			deployed = "yes"
+		}
+
+		values = append(values, []string{tagInfo.Tag, shortDigest(tagInfo.Digest), formatImageSize(tagInfo.Size), formatCreated(tagInfo.Created), deployed})
+	}
+
+	log.PrintTable([]string{"Tag", "Digest", "Size", "Created", "Deployed"}, values)
+}
+
+// RunInspect executes the registry inspect command logic
+func (cmd *RegistryCmd) RunInspect(cobraCmd *cobra.Command, args []string) {
+	defer cmd.closeClients()
+
+	name, tag := args[0], ""
+	if index := strings.LastIndex(name, ":"); index > strings.LastIndex(name, "/") {
+		name, tag = name[:index], name[index+1:]
+	}
+
+	registryName, repository, deployedTag, err := cmd.getRepository(name)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if tag == "" {
+		tag = deployedTag
+	}
+	if tag == "" {
+		tag = "latest"
+	}
+
+	client, err := cmd.getClient(registryName)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.StartWait("Reading manifest of " + repository + ":" + tag)
+	manifest, err := client.GetManifest(repository, tag)
+	log.StopWait()
+
+	if err != nil {
+		log.Fatalf("Error reading manifest of %s:%s: %v", repository, tag, err)
+	}
+
+	created := "-"
+	if manifest.ConfigDigest != "" {
+		createdTime, err := client.GetImageCreated(repository, manifest.ConfigDigest)
+		if err != nil {
+			log.Fatalf("Error reading image config of %s:%s: %v", repository, tag, err)
+		}
+
+		created = formatCreated(createdTime)
+	}
+
+	log.PrintTable([]string{"Property", "Value"}, [][]string{
+		{"Registry", registryName + " (" + getRegistryURL(registryName) + ")"},
+		{"Repository", repository},
+		{"Tag", tag},
+		{"Digest", manifest.Digest},
+		{"Media Type", manifest.MediaType},
+		{"Size", formatImageSize(manifest.Size)},
+		{"Layers", strconv.Itoa(manifest.Layers)},
+		{"Created", created},
+	})
+}
+
+// getRepository returns the registry, the repository and the deployed tag of an image in the config.
+// With --registry, name is a repository in this registry and there is no deployed tag
+func (cmd *RegistryCmd) getRepository(name string) (string, string, string, error) {
+	if cmd.flags.registry != "" {
+		return cmd.flags.registry, name, "", nil
+	}
+
+	config := configutil.GetConfig(false)
+
+	imageConf, ok := (*config.Images)[name]
+	if ok == false {
+		return "", "", "", fmt.Errorf("Image '%s' not found in config (use --registry to show a repository that is not in the config)", name)
+	}
+	if imageConf.Name == nil || imageConf.Registry == nil {
+		return "", "", "", fmt.Errorf("Image '%s' has no name or registry in the config", name)
+	}
+
+	deployedTag := ""
+	if imageConf.Tag != nil {
+		deployedTag = *imageConf.Tag
+	}
+
+	return *imageConf.Registry, *imageConf.Name, deployedTag, nil
+}
+
+// getRegistryURL returns the url of the registry in the config
+func getRegistryURL(registryName string) string {
+	config := configutil.GetConfig(false)
+
+	registryConf, ok := (*config.Registries)[registryName]
+	if ok == false || registryConf.URL == nil || *registryConf.URL == "" {
+		return "hub.docker.com"
+	}
+
+	return *registryConf.URL
+}
+
+// shortDigest returns the first 12 characters of the hash of a digest like docker images does
+func shortDigest(digest string) string {
+	hash := strings.TrimPrefix(digest, "sha256:")
+	if len(hash) > 12 {
+		hash = hash[:12]
+	}
+
+	return hash
+}
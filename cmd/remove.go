@@ -171,13 +171,19 @@ func (cmd *RemoveCmd) RunRemovePort(cobraCmd *cobra.Command, args []string) {
 OUTER:
 	for _, v := range *config.DevSpace.PortForwarding {
 		if cmd.portFlags.RemoveAll ||
-			isMapEqual(labelSelectorMap, *v.LabelSelector) {
+			(v.LabelSelector != nil && isMapEqual(labelSelectorMap, *v.LabelSelector)) {
 			continue
 		}
 
-		for _, pm := range *v.PortMappings {
-			if containsPort(strconv.Itoa(*pm.LocalPort), ports) || containsPort(strconv.Itoa(*pm.RemotePort), ports) {
-				continue OUTER
+		for _, portMappings := range []*[]*v1.PortMapping{v.PortMappings, v.ReversePortMappings} {
+			if portMappings == nil {
+				continue
+			}
+
+			for _, pm := range *portMappings {
+				if containsPort(string(*pm.LocalPort), ports) || containsPort(strconv.Itoa(*pm.RemotePort), ports) {
+					continue OUTER
+				}
 			}
 		}
 
@@ -3,7 +3,9 @@ package cmd
 import (
 	"os"
 	"path"
+	"sort"
 
+	"github.com/covexo/devspace/pkg/devspace/builder/buildkit"
 	helmClient "github.com/covexo/devspace/pkg/devspace/clients/helm"
 	"github.com/covexo/devspace/pkg/devspace/clients/kubectl"
 	"github.com/covexo/devspace/pkg/util/log"
@@ -31,6 +33,7 @@ type ResetCmdFlags struct {
 	deleteTiller         bool
 	deleteDevspaceFolder bool
 	deleteRelease        bool
+	deleteBuildkitPods   bool
 }
 
 func init() {
@@ -49,7 +52,8 @@ Resets your project by removing all DevSpace related
 data from your project and your cluster, including:
 1. DevSpace release (cluster)
 2. Docker registry (cluster)
-3. DevSpace config files in .devspace/ (local)
+3. BuildKit pods (cluster)
+4. DevSpace config files in .devspace/ (local)
 
 Use the flag --all-data to also remove:
 1. Tiller server (cluster)
@@ -79,6 +83,16 @@ func (cmd *ResetCmd) Run(cobraCmd *cobra.Command, args []string) {
 		}
 	}
 
+	if cmd.flags.deleteBuildkitPods {
+		err = cmd.deleteBuildkitPods()
+
+		if err != nil {
+			log.Failf("Error deleting BuildKit pods: %s", err.Error())
+		} else {
+			log.Done("Successfully deleted BuildKit pods")
+		}
+	}
+
 	if cmd.flags.deleteRegistry {
 		err = cmd.deleteRegistry()
 
@@ -155,6 +169,7 @@ func (cmd *ResetCmd) determineResetExtent() {
 
 	cmd.flags.deleteDevspaceFolder = true
 	cmd.flags.deleteRelease = true
+	cmd.flags.deleteBuildkitPods = len(getBuildkitNamespaces()) > 0
 
 	cmd.flags.deleteDockerfile = *stdinutil.GetFromStdin(&stdinutil.GetFromStdinParams{
 		Question:               "Should the Dockerfile be removed? (y/n)",
@@ -253,6 +268,57 @@ func (cmd *ResetCmd) deleteRegistry() error {
 	return err
 }
 
+func (cmd *ResetCmd) deleteBuildkitPods() error {
+	var err error
+
+	if cmd.kubectl == nil {
+		cmd.kubectl, err = kubectl.NewClient()
+
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, namespace := range getBuildkitNamespaces() {
+		err = buildkit.DeletePods(cmd.kubectl, namespace)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getBuildkitNamespaces returns the namespaces the images with the buildkit engine are built in
+func getBuildkitNamespaces() []string {
+	config := configutil.GetConfig(false)
+	namespaceMap := map[string]bool{}
+
+	if config.Images != nil {
+		for _, imageConf := range *config.Images {
+			if imageConf.Build == nil || imageConf.Build.Engine == nil || imageConf.Build.Engine.Buildkit == nil {
+				continue
+			}
+
+			namespace := *config.DevSpace.Release.Namespace
+			if imageConf.Build.Engine.Buildkit.Namespace != nil {
+				namespace = *imageConf.Build.Engine.Buildkit.Namespace
+			}
+
+			namespaceMap[namespace] = true
+		}
+	}
+
+	namespaces := make([]string, 0, len(namespaceMap))
+	for namespace := range namespaceMap {
+		namespaces = append(namespaces, namespace)
+	}
+
+	sort.Strings(namespaces)
+
+	return namespaces
+}
+
 func (cmd *ResetCmd) deleteTiller() error {
 	var err error
 	config := configutil.GetConfig(false)
@@ -63,6 +63,23 @@ func init() {
 	}
 
 	statusCmd.AddCommand(statusSyncCmd)
+
+	statusPortsCmd := &cobra.Command{
+		Use:   "ports",
+		Short: "Shows the port forwarding status",
+		Long: `
+	#######################################################
+	############### devspace status ports #################
+	#######################################################
+	Shows the state of the forwarded ports and the
+	transferred bytes
+	#######################################################
+	`,
+		Args: cobra.NoArgs,
+		Run:  cmd.RunStatusPorts,
+	}
+
+	statusCmd.AddCommand(statusPortsCmd)
 }
 
 // RunStatus executes the devspace status command logic
@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/covexo/devspace/pkg/util/log"
+	"github.com/spf13/cobra"
+)
+
+// portStatus is a status line written to the port forwarding log
+type portStatus struct {
+	Name          string `json:"name"`
+	Pod           string `json:"pod"`
+	Port          string `json:"port"`
+	Reverse       bool   `json:"reverse"`
+	State         string `json:"state"`
+	Error         string `json:"error"`
+	BytesSent     int64  `json:"bytesSent"`
+	BytesReceived int64  `json:"bytesReceived"`
+	Time          string `json:"time"`
+
+	// RunStart marks the start of a new run, all states logged before belong to an earlier run
+	RunStart bool `json:"runStart"`
+}
+
+// RunStatusPorts executes the devspace status ports command logic
+func (cmd *StatusCmd) RunStatusPorts(cobraCmd *cobra.Command, args []string) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	portLogPath := filepath.Join(cwd, ".devspace", "logs", "portforwarding.log")
+	data, err := ioutil.ReadFile(portLogPath)
+	if err != nil {
+		log.Fatalf("Couldn't read %s. Do you have a port forwarding configured? (check `devspace list port`)", portLogPath)
+	}
+
+	portMap := make(map[string]*portStatus)
+	lines := strings.Split(string(data), "\n")
+
+	for _, line := range lines {
+		if line == "" {
+			continue
+		}
+
+		status := &portStatus{}
+		err = json.Unmarshal([]byte(line), status)
+		if err != nil {
+			log.Fatalf("Error parsing %s: %v", portLogPath, err)
+		}
+
+		if status.RunStart {
+			portMap = make(map[string]*portStatus)
+			continue
+		}
+
+		// The log also contains messages that are not status lines
+		if status.Port == "" || status.State == "" {
+			continue
+		}
+
+		identifier := status.Name + ":" + status.Port
+		if status.Reverse {
+			identifier += ":reverse"
+		}
+
+		portMap[identifier] = status
+	}
+
+	if len(portMap) == 0 {
+		log.Info("No port forwarding activity found. Did you run `devspace up`?")
+		return
+	}
+
+	identifiers := make([]string, 0, len(portMap))
+	for identifier := range portMap {
+		identifiers = append(identifiers, identifier)
+	}
+
+	sort.Strings(identifiers)
+
+	// Print table
+	header := []string{
+		"Status",
+		"Name",
+		"Pod",
+		"Port (Local:Remote)",
+		"Sent",
+		"Received",
+		"Latest Activity",
+	}
+
+	values := make([][]string, 0, len(portMap))
+
+	for _, identifier := range identifiers {
+		status := portMap[identifier]
+
+		port := status.Port
+		if status.Reverse {
+			port += " (reverse)"
+		}
+
+		latestActivity := "State changed to " + status.State
+		if status.Error != "" {
+			latestActivity = status.Error
+		}
+
+		parsedTime, _ := time.Parse(time.RFC3339, status.Time)
+		latestActivity += " (" + intToTimeString(int(time.Now().Unix()-parsedTime.Unix())) + " ago)"
+
+		sent, received := bytesToString(status.BytesSent), bytesToString(status.BytesReceived)
+		if status.Reverse {
+			sent, received = "-", "-"
+		}
+
+		values = append(values, []string{
+			status.State,
+			status.Name,
+			status.Pod,
+			port,
+			sent,
+			received,
+			latestActivity,
+		})
+	}
+
+	log.PrintTable(header, values)
+}
+
+func bytesToString(bytes int64) string {
+	units := []string{"B", "KB", "MB", "GB"}
+	value := float64(bytes)
+	unit := 0
+
+	for value >= 1024 && unit < len(units)-1 {
+		value /= 1024
+		unit++
+	}
+
+	if unit == 0 {
+		return strconv.FormatInt(bytes, 10) + " " + units[unit]
+	}
+
+	return strconv.FormatFloat(value, 'f', 1, 64) + " " + units[unit]
+}
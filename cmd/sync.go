@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"strconv"
+
+	synctool "github.com/covexo/devspace/pkg/devspace/sync"
+	"github.com/covexo/devspace/pkg/util/log"
+	"github.com/spf13/cobra"
+)
+
+// SyncCmd holds the information needed for the sync command
+type SyncCmd struct {
+	flags *SyncCmdFlags
+}
+
+// SyncCmdFlags holds the possible flags for the sync command
+type SyncCmdFlags struct {
+}
+
+func init() {
+	cmd := &SyncCmd{
+		flags: &SyncCmdFlags{},
+	}
+
+	syncCmd := &cobra.Command{
+		Use:   "sync",
+		Short: "Controls the running sync",
+		Long: `
+	#######################################################
+	#################### devspace sync ####################
+	#######################################################
+	Controls the sync of a running devspace up:
+
+	* Pause the sync (pause)
+	* Resume the sync (resume)
+	* Apply pending changes immediately (flush)
+	* Show the sync state (status)
+	#######################################################
+	`,
+		Args: cobra.NoArgs,
+	}
+
+	rootCmd.AddCommand(syncCmd)
+
+	syncPauseCmd := &cobra.Command{
+		Use:   "pause",
+		Short: "Pauses the sync",
+		Long: `
+	#######################################################
+	################# devspace sync pause #################
+	#######################################################
+	Pauses the sync in both directions. Local changes are
+	buffered and uploaded when the sync is resumed
+	#######################################################
+	`,
+		Args: cobra.NoArgs,
+		Run:  cmd.RunSyncPause,
+	}
+
+	syncCmd.AddCommand(syncPauseCmd)
+
+	syncResumeCmd := &cobra.Command{
+		Use:   "resume",
+		Short: "Resumes the sync",
+		Long: `
+	#######################################################
+	################ devspace sync resume #################
+	#######################################################
+	Resumes a paused sync and uploads all local changes
+	that happened while the sync was paused
+	#######################################################
+	`,
+		Args: cobra.NoArgs,
+		Run:  cmd.RunSyncResume,
+	}
+
+	syncCmd.AddCommand(syncResumeCmd)
+
+	syncFlushCmd := &cobra.Command{
+		Use:   "flush",
+		Short: "Applies pending changes immediately",
+		Long: `
+	#######################################################
+	################# devspace sync flush #################
+	#######################################################
+	Applies all pending changes in both directions and
+	returns after they were applied
+	#######################################################
+	`,
+		Args: cobra.NoArgs,
+		Run:  cmd.RunSyncFlush,
+	}
+
+	syncCmd.AddCommand(syncFlushCmd)
+
+	syncStatusCmd := &cobra.Command{
+		Use:   "status",
+		Short: "Shows the state of the running sync",
+		Long: `
+	#######################################################
+	################ devspace sync status #################
+	#######################################################
+	Shows if the running sync paths are paused
+	#######################################################
+	`,
+		Args: cobra.NoArgs,
+		Run:  cmd.RunSyncStatus,
+	}
+
+	syncCmd.AddCommand(syncStatusCmd)
+}
+
+// RunSyncPause executes the devspace sync pause command logic
+func (cmd *SyncCmd) RunSyncPause(cobraCmd *cobra.Command, args []string) {
+	cmd.sendCommand(synctool.ControlPause)
+	log.Done("Sync paused")
+}
+
+// RunSyncResume executes the devspace sync resume command logic
+func (cmd *SyncCmd) RunSyncResume(cobraCmd *cobra.Command, args []string) {
+	log.StartWait("Resuming sync")
+	cmd.sendCommand(synctool.ControlResume)
+	log.StopWait()
+
+	log.Done("Sync resumed")
+}
+
+// RunSyncFlush executes the devspace sync flush command logic
+func (cmd *SyncCmd) RunSyncFlush(cobraCmd *cobra.Command, args []string) {
+	log.StartWait("Flushing sync")
+	cmd.sendCommand(synctool.ControlFlush)
+	log.StopWait()
+
+	log.Done("All pending changes applied")
+}
+
+// RunSyncStatus executes the devspace sync status command logic
+func (cmd *SyncCmd) RunSyncStatus(cobraCmd *cobra.Command, args []string) {
+	response := cmd.sendCommand(synctool.ControlStatus)
+
+	headerColumnNames := []string{
+		"Status",
+		"Pod",
+		"Local",
+		"Container",
+		"Pending Changes",
+	}
+
+	values := make([][]string, 0, len(response.Syncs))
+
+	for _, syncStatus := range response.Syncs {
+		status := "Active"
+		if syncStatus.Paused {
+			status = "Paused"
+		}
+
+		values = append(values, []string{
+			status,
+			syncStatus.Pod,
+			syncStatus.Local,
+			syncStatus.Container,
+			strconv.Itoa(syncStatus.PendingChanges),
+		})
+	}
+
+	log.PrintTable(headerColumnNames, values)
+}
+
+func (cmd *SyncCmd) sendCommand(command string) *synctool.ControlResponse {
+	response, err := synctool.SendControlCommand(command)
+	if err != nil {
+		log.StopWait()
+		log.Fatal(err)
+	}
+
+	failed := false
+
+	for _, syncStatus := range response.Syncs {
+		if syncStatus.Error != "" {
+			log.StopWait()
+			log.Errorf("Sync %s <-> %s: %s", syncStatus.Local, syncStatus.Container, syncStatus.Error)
+			failed = true
+		}
+	}
+
+	if failed {
+		log.Fatalf("Couldn't %s sync", command)
+	}
+
+	return response
+}
@@ -4,25 +4,20 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/covexo/devspace/pkg/util/yamlutil"
 
-	"github.com/covexo/devspace/pkg/devspace/builder"
-
-	"github.com/docker/docker/api/types"
-
-	"github.com/covexo/devspace/pkg/devspace/builder/docker"
-
 	"github.com/covexo/devspace/pkg/devspace/config/v1"
 
-	"github.com/covexo/devspace/pkg/util/randutil"
-
 	"github.com/covexo/devspace/pkg/util/log"
 
-	"github.com/covexo/devspace/pkg/devspace/builder/kaniko"
+	"github.com/covexo/devspace/pkg/devspace/image"
+	"github.com/covexo/devspace/pkg/devspace/portforward"
 	"github.com/covexo/devspace/pkg/devspace/registry"
 	synctool "github.com/covexo/devspace/pkg/devspace/sync"
 
@@ -45,31 +40,43 @@ type UpCmd struct {
 	workdir   string
 	pod       *k8sv1.Pod
 	container *k8sv1.Container
+
+	// The pod and the services attached to it are replaced when the images are rebuilt by the watcher
+	reloadMutex     sync.Mutex
+	reloadDone      chan bool
+	reloadCount     int
+	syncConfigs     []*synctool.SyncConfig
+	portForwardings []*portforward.PortForwarding
+	controlServer   *synctool.ControlServer
 }
 
 // UpCmdFlags are the flags available for the up-command
 type UpCmdFlags struct {
-	tiller         bool
-	open           string
-	initRegistries bool
-	build          bool
-	shell          string
-	sync           bool
-	deploy         bool
-	portforwarding bool
-	noSleep        bool
+	tiller           bool
+	open             string
+	initRegistries   bool
+	build            bool
+	shell            string
+	sync             bool
+	deploy           bool
+	portforwarding   bool
+	printRequests    bool
+	noSleep          bool
+	buildParallelism int
 }
 
 //UpFlagsDefault are the default flags for UpCmdFlags
 var UpFlagsDefault = &UpCmdFlags{
-	tiller:         true,
-	open:           "cmd",
-	initRegistries: true,
-	build:          true,
-	sync:           true,
-	deploy:         false,
-	portforwarding: true,
-	noSleep:        false,
+	tiller:           true,
+	open:             "cmd",
+	initRegistries:   true,
+	build:            true,
+	sync:             true,
+	deploy:           false,
+	portforwarding:   true,
+	printRequests:    false,
+	noSleep:          false,
+	buildParallelism: image.DefaultParallelism,
 }
 
 func init() {
@@ -86,10 +93,13 @@ func init() {
 #######################################################
 Starts and connects your DevSpace:
 1. Connects to the Tiller server
-2. Builds your Docker image (if your Dockerfile has changed)
+2. Builds your Docker image (if your build context has changed)
 3. Deploys the Helm chart in /chart
 4. Starts the sync client
 5. Enters the container shell
+
+Images with build.watch enabled are rebuilt and redeployed
+when their Dockerfile or rebuildOn files change
 #######################################################`,
 		Args: cobra.NoArgs,
 		Run:  cmd.Run,
@@ -98,10 +108,12 @@ Starts and connects your DevSpace:
 
 	cobraCmd.Flags().BoolVar(&cmd.flags.tiller, "tiller", cmd.flags.tiller, "Install/upgrade tiller")
 	cobraCmd.Flags().BoolVar(&cmd.flags.initRegistries, "init-registries", cmd.flags.initRegistries, "Initialize registries (and install internal one)")
-	cobraCmd.Flags().BoolVarP(&cmd.flags.build, "build", "b", cmd.flags.build, "Build image if Dockerfile has been modified")
+	cobraCmd.Flags().BoolVarP(&cmd.flags.build, "build", "b", cmd.flags.build, "Build image if the build context has been modified")
+	cobraCmd.Flags().IntVar(&cmd.flags.buildParallelism, "build-parallelism", cmd.flags.buildParallelism, "Maximum number of images that are built at the same time")
 	cobraCmd.Flags().StringVarP(&cmd.flags.shell, "shell", "s", "", "Shell command (default: bash, fallback: sh)")
 	cobraCmd.Flags().BoolVar(&cmd.flags.sync, "sync", cmd.flags.sync, "Enable code synchronization")
 	cobraCmd.Flags().BoolVar(&cmd.flags.portforwarding, "portforwarding", cmd.flags.portforwarding, "Enable port forwarding")
+	cobraCmd.Flags().BoolVar(&cmd.flags.printRequests, "print-requests", cmd.flags.printRequests, "Print a summary line for every request to ports with inspect: true")
 	cobraCmd.Flags().BoolVarP(&cmd.flags.deploy, "deploy", "d", cmd.flags.deploy, "Deploy chart")
 	cobraCmd.Flags().BoolVar(&cmd.flags.noSleep, "no-sleep", cmd.flags.noSleep, "Enable no-sleep")
 }
@@ -126,6 +138,12 @@ func (cmd *UpCmd) Run(cobraCmd *cobra.Command, args []string) {
 		initCmd.Run(nil, []string{})
 	}
 
+	// Fail before anything is deployed if an image can't be built or deployed
+	err = image.ValidateImages(configutil.GetConfig(false), cmd.workdir)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	cmd.kubectl, err = kubectl.NewClient()
 	if err != nil {
 		log.Fatalf("Unable to create new kubectl client: %v", err)
@@ -144,29 +162,57 @@ func (cmd *UpCmd) Run(cobraCmd *cobra.Command, args []string) {
 	mustRedeploy := false
 
 	if cmd.flags.build {
-		mustRedeploy = cmd.buildImages(cobraCmd.Flags().Changed("build"))
+		buildResults, err := image.BuildImages(&image.BuildOptions{
+			Workdir:     cmd.workdir,
+			Force:       cobraCmd.Flags().Changed("build"),
+			Parallelism: cmd.flags.buildParallelism,
+			Kubectl:     cmd.kubectl,
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		for _, buildResult := range buildResults {
+			if buildResult.Built || buildResult.Cached {
+				mustRedeploy = true
+			}
+		}
 	}
 
 	// Check if we find a running release pod
 	pod, err := getRunningDevSpacePod(cmd.helm, cmd.kubectl)
 
 	if err != nil || mustRedeploy || cmd.flags.deploy {
-		cmd.deployChart()
+		err = cmd.deployChart(log.GetInstance())
+		if err != nil {
+			log.Fatal(err)
+		}
 	} else {
-		cmd.pod = pod
+		cmd.setPod(pod)
 	}
 
+	defer cmd.stopServices()
+
 	if cmd.flags.portforwarding {
-		cmd.startPortForwarding()
+		cmd.portForwardings = cmd.startPortForwarding(nil)
 	}
 
 	if cmd.flags.sync {
-		syncConfigs := cmd.startSync()
-		defer func() {
-			for _, v := range syncConfigs {
-				v.Stop()
+		cmd.syncConfigs = cmd.startSync(nil)
+
+		if len(cmd.syncConfigs) > 0 {
+			cmd.controlServer, err = synctool.StartControlServer(cmd.syncConfigs)
+			if err != nil {
+				log.Warnf("Unable to start sync control server, `devspace sync` will not be available: %v", err)
 			}
-		}()
+		}
+	}
+
+	if cmd.flags.build {
+		watcher := cmd.startRebuildWatcher()
+		if watcher != nil {
+			defer watcher.Stop()
+		}
 	}
 
 	cmd.enterTerminal()
@@ -245,171 +291,6 @@ func (cmd *UpCmd) initRegistries() {
 	}
 }
 
-func (cmd *UpCmd) shouldRebuild(imageConf *v1.ImageConfig, dockerfilePath string, buildFlagChanged bool) bool {
-	var dockerfileModTime time.Time
-
-	mustRebuild := true
-	dockerfileInfo, err := os.Stat(dockerfilePath)
-
-	if err != nil {
-		if imageConf.Build.LatestTimestamp == nil {
-			log.Fatalf("Dockerfile missing: %v", err)
-		} else {
-			mustRebuild = false
-		}
-	} else {
-		dockerfileModTime = dockerfileInfo.ModTime()
-
-		// When user has not used -b or --build flags
-		if buildFlagChanged == false {
-			if imageConf.Build.LatestTimestamp != nil {
-				latestBuildTime, _ := time.Parse(time.RFC3339Nano, *imageConf.Build.LatestTimestamp)
-
-				// only rebuild Docker image when Dockerfile has changed since latest build
-				mustRebuild = (latestBuildTime.Equal(dockerfileModTime) == false)
-			}
-		}
-	}
-	imageConf.Build.LatestTimestamp = configutil.String(dockerfileModTime.Format(time.RFC3339Nano))
-
-	return mustRebuild
-}
-
-// returns true when one of the images had to be rebuild
-func (cmd *UpCmd) buildImages(buildFlagChanged bool) bool {
-	re := false
-	config := configutil.GetConfig(false)
-
-	for imageName, imageConf := range *config.Images {
-		dockerfilePath := "./Dockerfile"
-		contextPath := "./"
-
-		if imageConf.Build.DockerfilePath != nil {
-			dockerfilePath = *imageConf.Build.DockerfilePath
-		}
-
-		if imageConf.Build.ContextPath != nil {
-			contextPath = *imageConf.Build.ContextPath
-		}
-		dockerfilePath = filepath.Join(cmd.workdir, strings.TrimPrefix(dockerfilePath, "."))
-		contextPath = filepath.Join(cmd.workdir, strings.TrimPrefix(contextPath, "."))
-
-		if cmd.shouldRebuild(imageConf, dockerfilePath, buildFlagChanged) {
-			re = true
-			imageTag, randErr := randutil.GenerateRandomString(7)
-
-			if randErr != nil {
-				log.Fatalf("Image building failed: %s", randErr.Error())
-			}
-			registryConf, err := registry.GetRegistryConfig(imageConf)
-			if err != nil {
-				log.Fatal(err)
-			}
-
-			var imageBuilder builder.Interface
-
-			buildInfo := "Building image '%s' with engine '%s'"
-			engineName := ""
-			registryURL := ""
-
-			if registryConf.URL != nil {
-				registryURL = *registryConf.URL
-			}
-			if registryURL == "hub.docker.com" {
-				registryURL = ""
-			}
-
-			if imageConf.Build.Engine.Kaniko != nil {
-				engineName = "kaniko"
-				buildNamespace := *config.DevSpace.Release.Namespace
-				allowInsecurePush := false
-
-				if imageConf.Build.Engine.Kaniko.Namespace != nil {
-					buildNamespace = *imageConf.Build.Engine.Kaniko.Namespace
-				}
-
-				if registryConf.Insecure != nil {
-					allowInsecurePush = *registryConf.Insecure
-				}
-				imageBuilder, err = kaniko.NewBuilder(registryURL, *imageConf.Name, imageTag, buildNamespace, cmd.kubectl, allowInsecurePush)
-				if err != nil {
-					log.Fatalf("Error creating kaniko builder: %v", err)
-				}
-			} else {
-				engineName = "docker"
-				preferMinikube := true
-
-				if imageConf.Build.Engine.Docker.PreferMinikube != nil {
-					preferMinikube = *imageConf.Build.Engine.Docker.PreferMinikube
-				}
-
-				imageBuilder, err = docker.NewBuilder(registryURL, *imageConf.Name, imageTag, preferMinikube)
-				if err != nil {
-					log.Fatalf("Error creating docker client: %v", err)
-				}
-			}
-
-			log.Infof(buildInfo, imageName, engineName)
-
-			username := ""
-			password := ""
-
-			if registryConf.URL != nil {
-				registryURL = *registryConf.URL
-			}
-			if registryConf.Auth != nil {
-				if registryConf.Auth.Username != nil {
-					username = *registryConf.Auth.Username
-				}
-
-				if registryConf.Auth.Password != nil {
-					password = *registryConf.Auth.Password
-				}
-			}
-
-			log.StartWait("Authenticating (" + registryURL + ")")
-			_, err = imageBuilder.Authenticate(username, password, len(username) == 0)
-			log.StopWait()
-
-			if err != nil {
-				log.Fatalf("Error during image registry authentication: %v", err)
-			}
-
-			log.Done("Authentication successful (" + registryURL + ")")
-
-			buildOptions := &types.ImageBuildOptions{}
-			if imageConf.Build.Options != nil {
-				if imageConf.Build.Options.BuildArgs != nil {
-					buildOptions.BuildArgs = *imageConf.Build.Options.BuildArgs
-				}
-			}
-
-			err = imageBuilder.BuildImage(contextPath, dockerfilePath, buildOptions)
-			if err != nil {
-				log.Fatalf("Error during image build: %v", err)
-			}
-
-			err = imageBuilder.PushImage()
-			if err != nil {
-				log.Fatalf("Error during image push: %v", err)
-			}
-
-			log.Info("Image pushed to registry (" + registryURL + ")")
-			imageConf.Tag = &imageTag
-
-			err = configutil.SaveConfig()
-			if err != nil {
-				log.Fatalf("Config saving error: %s", err.Error())
-			}
-
-			log.Done("Done building and pushing image '" + imageName + "'")
-		} else {
-			log.Infof("Skip building image '%s'", imageName)
-		}
-	}
-	return re
-}
-
 func (cmd *UpCmd) initHelm() {
 	if cmd.helm == nil {
 		log.StartWait("Initializing helm client")
@@ -425,10 +306,11 @@ func (cmd *UpCmd) initHelm() {
 	}
 }
 
-func (cmd *UpCmd) deployChart() {
+func (cmd *UpCmd) deployChart(deployLog log.Logger) error {
 	config := configutil.GetConfig(false)
 
-	log.StartWait("Deploying helm chart")
+	deployLog.StartWait("Deploying helm chart")
+	defer deployLog.StopWait()
 
 	releaseName := *config.DevSpace.Release.Name
 	releaseNamespace := *config.DevSpace.Release.Namespace
@@ -439,14 +321,19 @@ func (cmd *UpCmd) deployChart() {
 
 	err := yamlutil.ReadYamlFromFile(chartPath+"values.yaml", values)
 	if err != nil {
-		log.Fatalf("Couldn't deploy chart, error reading from chart values %s: %v", chartPath+"values.yaml", err)
+		return fmt.Errorf("Couldn't deploy chart, error reading from chart values %s: %v", chartPath+"values.yaml", err)
 	}
 
 	containerValues := map[string]interface{}{}
 
 	for imageName, imageConf := range *config.Images {
 		container := map[string]interface{}{}
-		container["image"] = registry.GetImageURL(imageConf, true)
+		container["image"] = registry.GetDeployImageURL(imageConf)
+
+		// Local images can't be pulled, because they were never pushed
+		if image.IsLocalImage(imageConf) {
+			container["imagePullPolicy"] = "IfNotPresent"
+		}
 
 		if cmd.flags.noSleep {
 			container["command"] = []string{}
@@ -475,16 +362,15 @@ func (cmd *UpCmd) deployChart() {
 
 	appRelease, err := cmd.helm.InstallChartByPath(releaseName, releaseNamespace, chartPath, &overwriteValues)
 
-	log.StopWait()
+	deployLog.StopWait()
 
 	if err != nil {
-		log.Fatalf("Unable to deploy helm chart: %s", err.Error())
+		return fmt.Errorf("Unable to deploy helm chart: %v", err)
 	}
 
 	releaseRevision := int(appRelease.Version)
-	log.Donef("Deployed helm chart (Release revision: %d)", releaseRevision)
-	log.StartWait("Waiting for release pod to become ready")
-	defer log.StopWait()
+	deployLog.Donef("Deployed helm chart (Release revision: %d)", releaseRevision)
+	deployLog.StartWait("Waiting for release pod to become ready")
 
 	for true {
 		podList, err := cmd.kubectl.Core().Pods(releaseNamespace).List(metav1.ListOptions{
@@ -492,7 +378,7 @@ func (cmd *UpCmd) deployChart() {
 		})
 
 		if err != nil {
-			log.Panicf("Unable to list devspace pods: %s", err.Error())
+			return fmt.Errorf("Unable to list devspace pods: %v", err)
 		}
 
 		if len(podList.Items) > 0 {
@@ -520,31 +406,31 @@ func (cmd *UpCmd) deployChart() {
 
 			if !hasRevision || highestRevision == releaseRevision {
 				if !hasRevision {
-					log.Warn("Found pod without revision. Use annotation 'revision' for your pods to avoid this warning.")
+					deployLog.Warn("Found pod without revision. Use annotation 'revision' for your pods to avoid this warning.")
 				}
 
-				cmd.pod = &selectedPod
-				err = waitForPodReady(cmd.kubectl, cmd.pod, 2*60*time.Second, 5*time.Second)
-
+				err = waitForPodReady(cmd.kubectl, &selectedPod, 2*60*time.Second, 5*time.Second)
 				if err != nil {
-					log.Fatalf("Error during waiting for pod: %s", err.Error())
+					return fmt.Errorf("Error during waiting for pod: %v", err)
 				}
 
+				cmd.setPod(&selectedPod)
 				break
 			} else {
-				log.Info("Waiting for release upgrade to complete.")
+				deployLog.Info("Waiting for release upgrade to complete.")
 			}
 		} else {
-			log.Info("Waiting for release to be deployed.")
+			deployLog.Info("Waiting for release to be deployed.")
 		}
 
 		time.Sleep(2 * time.Second)
 	}
 
-	log.StopWait()
+	return nil
 }
 
-func (cmd *UpCmd) startSync() []*synctool.SyncConfig {
+// startSync starts all configured syncs. Syncs with a key in paused (see getSyncKey) are started paused
+func (cmd *UpCmd) startSync(paused map[string]bool) []*synctool.SyncConfig {
 	config := configutil.GetConfig(false)
 	syncConfigs := make([]*synctool.SyncConfig, 0, len(*config.DevSpace.Sync))
 
@@ -569,7 +455,8 @@ func (cmd *UpCmd) startSync() []*synctool.SyncConfig {
 			pod, err := kubectl.GetFirstRunningPod(cmd.kubectl, strings.Join(labels, ", "), namespace)
 
 			if err != nil {
-				log.Panicf("Unable to list devspace pods: %s", err.Error())
+				log.Errorf("Unable to list devspace pods: %v", err)
+				continue
 			} else if pod != nil {
 				syncConfig := &synctool.SyncConfig{
 					Kubectl:   cmd.kubectl,
@@ -591,6 +478,12 @@ func (cmd *UpCmd) startSync() []*synctool.SyncConfig {
 					syncConfig.UploadExcludePaths = *syncPath.UploadExcludePaths
 				}
 
+				if syncPath.TransferWorkers != nil {
+					syncConfig.TransferWorkers = *syncPath.TransferWorkers
+				}
+
+				syncConfig.Paused = paused[getSyncKey(syncConfig)]
+
 				err = syncConfig.Start()
 				if err != nil {
 					log.Fatalf("Sync error: %s", err.Error())
@@ -605,51 +498,282 @@ func (cmd *UpCmd) startSync() []*synctool.SyncConfig {
 	return syncConfigs
 }
 
-func (cmd *UpCmd) startPortForwarding() {
+// getSyncKey identifies a sync across restarts
+func getSyncKey(syncConfig *synctool.SyncConfig) string {
+	return syncConfig.WatchPath + ":" + syncConfig.DestPath
+}
+
+// startPortForwarding starts all configured port forwardings. Automatically chosen local ports of the
+// previous port forwardings with the same name are kept
+func (cmd *UpCmd) startPortForwarding(previous []*portforward.PortForwarding) []*portforward.PortForwarding {
 	config := configutil.GetConfig(false)
+	portForwardings := make([]*portforward.PortForwarding, 0, len(*config.DevSpace.PortForwarding))
 
-	for _, portForwarding := range *config.DevSpace.PortForwarding {
-		if *portForwarding.ResourceType == "pod" {
-			if len(*portForwarding.LabelSelector) > 0 {
-				labels := make([]string, 0, len(*portForwarding.LabelSelector))
+	// The previous port forwardings are stopped at this point, so their states are not shown anymore
+	portforward.LogRunStart()
 
-				for key, value := range *portForwarding.LabelSelector {
-					labels = append(labels, key+"="+*value)
-				}
+	for _, portForwardingConfig := range *config.DevSpace.PortForwarding {
+		name := getPortForwardingName(portForwardingConfig)
+
+		portForwarding, err := cmd.resolvePortForwarding(portForwardingConfig)
+		if err != nil {
+			log.Errorf("Unable to start port forwarding %s: %v", name, err)
+			continue
+		} else if portForwarding == nil {
+			log.Warnf("Unable to start port forwarding %s: No running pod found", name)
+			continue
+		}
 
-				namespace := *config.DevSpace.Release.Namespace
-				if portForwarding.Namespace != nil && *portForwarding.Namespace != "" {
-					namespace = *portForwarding.Namespace
+		portForwarding.Name = name
+		portForwarding.PrintRequests = cmd.flags.printRequests
+
+		for _, previousPortForwarding := range previous {
+			if previousPortForwarding.Name == name {
+				keepLocalPorts(portForwarding.Ports, previousPortForwarding.Ports)
+			}
+		}
+
+		// The pod is looked up again when the connection is lost, e.g. because the pod was replaced
+		portForwarding.ResolvePod = func(portForwardingConfig *v1.PortForwardingConfig) func() (*k8sv1.Pod, error) {
+			return func() (*k8sv1.Pod, error) {
+				resolved, err := cmd.resolvePortForwarding(portForwardingConfig)
+				if err != nil || resolved == nil {
+					return nil, err
 				}
 
-				pod, err := kubectl.GetFirstRunningPod(cmd.kubectl, strings.Join(labels, ", "), namespace)
+				return resolved.Pod, nil
+			}
+		}(portForwardingConfig)
 
-				if err != nil {
-					log.Errorf("Unable to list devspace pods: %s", err.Error())
-				} else if pod != nil {
-					ports := make([]string, len(*portForwarding.PortMappings))
+		err = portForwarding.Start()
+		if err != nil {
+			log.Errorf("Unable to start port forwarding %s: %v", name, err)
+			continue
+		}
 
-					for index, value := range *portForwarding.PortMappings {
-						ports[index] = strconv.Itoa(*value.LocalPort) + ":" + strconv.Itoa(*value.RemotePort)
-					}
+		if len(portForwarding.Ports) > 0 {
+			log.Donef("Port forwarding %s started on %s", name, portsToString(portForwarding.Ports))
+		}
+		if len(portForwarding.ReversePorts) > 0 {
+			log.Donef("Reverse port forwarding %s started on %s", name, portsToString(portForwarding.ReversePorts))
+		}
 
-					readyChan := make(chan struct{})
+		portForwardings = append(portForwardings, portForwarding)
+	}
 
-					go kubectl.ForwardPorts(cmd.kubectl, pod, ports, make(chan struct{}), readyChan)
+	return portForwardings
+}
 
-					// Wait till forwarding is ready
-					select {
-					case <-readyChan:
-						log.Donef("Port forwarding started on %s", strings.Join(ports, ", "))
-					case <-time.After(5 * time.Second):
-						log.Error("Timeout waiting for port forwarding to start")
-					}
+func portsToString(ports []*portforward.Port) string {
+	portStrings := make([]string, 0, len(ports))
+	for _, port := range ports {
+		portStrings = append(portStrings, port.String())
+	}
+
+	return strings.Join(portStrings, ", ")
+}
+
+// resolvePortForwarding finds the pod the given port forwarding targets and creates the port forwarding,
+// remote ports of services are translated to pod ports
+func (cmd *UpCmd) resolvePortForwarding(portForwarding *v1.PortForwardingConfig) (*portforward.PortForwarding, error) {
+	config := configutil.GetConfig(false)
+
+	namespace := *config.DevSpace.Release.Namespace
+	if portForwarding.Namespace != nil && *portForwarding.Namespace != "" {
+		namespace = *portForwarding.Namespace
+	}
+
+	resourceName := ""
+	if portForwarding.ResourceName != nil {
+		resourceName = *portForwarding.ResourceName
+	}
+
+	labelSelector := ""
+	if portForwarding.LabelSelector != nil {
+		labels := make([]string, 0, len(*portForwarding.LabelSelector))
+
+		for key, value := range *portForwarding.LabelSelector {
+			labels = append(labels, key+"="+*value)
+		}
+
+		labelSelector = strings.Join(labels, ", ")
+	}
+
+	resourceType := getPortForwardingResourceType(portForwarding)
+
+	if resourceName == "" && labelSelector == "" {
+		return nil, fmt.Errorf("Neither resourceName nor labelSelector is specified")
+	}
+
+	var pod *k8sv1.Pod
+	var service *k8sv1.Service
+	var err error
+
+	switch resourceType {
+	case "pod":
+		if resourceName != "" {
+			pod, err = cmd.kubectl.Core().Pods(namespace).Get(resourceName, metav1.GetOptions{})
+		} else {
+			pod, err = kubectl.GetFirstRunningPod(cmd.kubectl, labelSelector, namespace)
+		}
+	case "service":
+		service, pod, err = kubectl.GetServicePod(cmd.kubectl, resourceName, labelSelector, namespace)
+	case "deployment":
+		pod, err = kubectl.GetDeploymentPod(cmd.kubectl, resourceName, labelSelector, namespace)
+	case "statefulset":
+		pod, err = kubectl.GetStatefulSetPod(cmd.kubectl, resourceName, labelSelector, namespace)
+	default:
+		return nil, fmt.Errorf("Unsupported resource type %s (supported are pod, service, deployment and statefulset)", resourceType)
+	}
+
+	if err != nil || pod == nil {
+		return nil, err
+	}
+
+	ports := []*portforward.Port{}
+	if portForwarding.PortMappings != nil {
+		for _, value := range *portForwarding.PortMappings {
+			port, err := getPort(value)
+			if err != nil {
+				return nil, err
+			}
+
+			if service != nil {
+				port.RemotePort, err = kubectl.GetServiceTargetPort(service, pod, port.RemotePort)
+				if err != nil {
+					return nil, err
 				}
 			}
-		} else {
-			log.Warn("Currently only pod resource type is supported for portforwarding")
+
+			ports = append(ports, port)
 		}
 	}
+
+	// Reverse ports are opened in the pod itself, so there is nothing to translate
+	reversePorts := []*portforward.Port{}
+	if portForwarding.ReversePortMappings != nil {
+		for _, value := range *portForwarding.ReversePortMappings {
+			port, err := getPort(value)
+			if err != nil {
+				return nil, err
+			}
+
+			if port.LocalPort == 0 {
+				return nil, fmt.Errorf("localPort: %s is not supported for reverse port mappings", v1.LocalPortAuto)
+			}
+			if port.HealthCheck != nil {
+				return nil, fmt.Errorf("healthCheck is not supported for reverse port mappings")
+			}
+			if port.Inspect {
+				return nil, fmt.Errorf("inspect is not supported for reverse port mappings")
+			}
+
+			reversePorts = append(reversePorts, port)
+		}
+	}
+
+	// Reverse ports are opened in this container, by default the first container of the pod
+	container := pod.Spec.Containers[0].Name
+	if portForwarding.ContainerName != nil && *portForwarding.ContainerName != "" {
+		container = *portForwarding.ContainerName
+
+		found := false
+		for _, podContainer := range pod.Spec.Containers {
+			if podContainer.Name == container {
+				found = true
+			}
+		}
+
+		if found == false {
+			return nil, fmt.Errorf("Container %s not found in pod %s", container, pod.Name)
+		}
+	}
+
+	return &portforward.PortForwarding{
+		Kubectl:      cmd.kubectl,
+		Pod:          pod,
+		Container:    container,
+		Ports:        ports,
+		ReversePorts: reversePorts,
+	}, nil
+}
+
+func getPort(portMapping *v1.PortMapping) (*portforward.Port, error) {
+	if portMapping.LocalPort == nil || portMapping.RemotePort == nil {
+		return nil, fmt.Errorf("localPort and remotePort have to be specified")
+	}
+
+	port := &portforward.Port{
+		RemotePort: *portMapping.RemotePort,
+	}
+
+	if portMapping.LocalPort.IsAuto() == false {
+		localPort, err := portMapping.LocalPort.Int()
+		if err != nil {
+			return nil, err
+		}
+
+		port.LocalPort = localPort
+	}
+
+	if portMapping.BindAddress != nil {
+		port.BindAddress = *portMapping.BindAddress
+	}
+
+	if portMapping.Inspect != nil {
+		port.Inspect = *portMapping.Inspect
+	}
+
+	if portMapping.HealthCheck != nil {
+		port.HealthCheck = &portforward.HealthCheck{
+			Type: portforward.HealthCheckTCP,
+		}
+
+		if portMapping.HealthCheck.Type != nil && *portMapping.HealthCheck.Type != "" {
+			port.HealthCheck.Type = *portMapping.HealthCheck.Type
+		}
+		if portMapping.HealthCheck.Path != nil {
+			port.HealthCheck.Path = *portMapping.HealthCheck.Path
+		}
+		if portMapping.HealthCheck.Interval != nil {
+			port.HealthCheck.Interval = time.Duration(*portMapping.HealthCheck.Interval) * time.Second
+		}
+	}
+
+	return port, nil
+}
+
+// getPortForwardingResourceType returns the configured resource type, which defaults to pod
+func getPortForwardingResourceType(portForwarding *v1.PortForwardingConfig) string {
+	if portForwarding.ResourceType != nil && *portForwarding.ResourceType != "" {
+		return *portForwarding.ResourceType
+	}
+
+	return "pod"
+}
+
+func getPortForwardingName(portForwarding *v1.PortForwardingConfig) string {
+	if portForwarding.Name != nil && *portForwarding.Name != "" {
+		return *portForwarding.Name
+	}
+
+	resourceType := getPortForwardingResourceType(portForwarding)
+
+	if portForwarding.ResourceName != nil && *portForwarding.ResourceName != "" {
+		return resourceType + "/" + *portForwarding.ResourceName
+	}
+
+	selector := make([]string, 0, 1)
+	if portForwarding.LabelSelector != nil {
+		for key, value := range *portForwarding.LabelSelector {
+			selector = append(selector, key+"="+*value)
+		}
+	}
+
+	// Map iteration order is random
+	sort.Strings(selector)
+
+	return resourceType + "/" + strings.Join(selector, ",")
 }
 
 func (cmd *UpCmd) enterTerminal() {
@@ -665,12 +789,24 @@ func (cmd *UpCmd) enterTerminal() {
 		shell = []string{cmd.flags.shell}
 	}
 
-	_, _, _, terminalErr := kubectl.Exec(cmd.kubectl, cmd.pod, cmd.pod.Spec.Containers[0].Name, shell, true, nil)
+	for {
+		pod, reloadCount := cmd.getPod()
 
-	if terminalErr != nil {
-		if _, ok := terminalErr.(exec.CodeExitError); ok == false {
-			log.Fatalf("Unable to start terminal session: %s", terminalErr.Error())
+		_, _, _, terminalErr := kubectl.Exec(cmd.kubectl, pod, pod.Spec.Containers[0].Name, shell, true, nil)
+
+		// The session ends when the pod is replaced by a redeploy, so we attach to the new pod
+		if cmd.waitForReload(reloadCount) {
+			log.Info("Reattaching terminal to the new pod")
+			continue
 		}
+
+		if terminalErr != nil {
+			if _, ok := terminalErr.(exec.CodeExitError); ok == false {
+				log.Fatalf("Unable to start terminal session: %s", terminalErr.Error())
+			}
+		}
+
+		return
 	}
 }
 
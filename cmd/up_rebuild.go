@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/covexo/devspace/pkg/devspace/config/configutil"
+	"github.com/covexo/devspace/pkg/devspace/image"
+	"github.com/covexo/devspace/pkg/devspace/portforward"
+	"github.com/covexo/devspace/pkg/util/log"
+	k8sv1 "k8s.io/api/core/v1"
+)
+
+// startRebuildWatcher watches the build files of all images with build.watch enabled. It returns nil
+// if no image enabled it
+func (cmd *UpCmd) startRebuildWatcher() *image.Watcher {
+	config := configutil.GetConfig(false)
+
+	patterns := image.GetWatchPatterns(config, cmd.workdir)
+	if len(patterns) == 0 {
+		return nil
+	}
+
+	imageNames := make([]string, 0, len(patterns))
+	for imageName := range patterns {
+		imageNames = append(imageNames, imageName)
+	}
+
+	sort.Strings(imageNames)
+
+	watcher := image.NewWatcher(cmd.workdir, patterns, cmd.rebuild)
+
+	err := watcher.Start()
+	if err != nil {
+		log.Warnf("Unable to watch the build files, images will not be rebuilt automatically: %v", err)
+		return nil
+	}
+
+	log.Donef("Watching the build files of %s", strings.Join(imageNames, ", "))
+
+	return watcher
+}
+
+// rebuild is called by the watcher, it rebuilds the changed images and redeploys the chart.
+// The build output is written to .devspace/logs/build.log, because the terminal is attached
+func (cmd *UpCmd) rebuild(imageNames []string) {
+	log.Infof("Build files of %s changed, rebuilding", strings.Join(imageNames, ", "))
+
+	_, err := image.BuildImages(&image.BuildOptions{
+		Workdir:     cmd.workdir,
+		ImageNames:  imageNames,
+		Force:       true,
+		Parallelism: cmd.flags.buildParallelism,
+		Kubectl:     cmd.kubectl,
+		Log:         log.GetFileLogger("build"),
+	})
+	if err != nil {
+		log.Errorf("Rebuilding failed, the deployment is unchanged (see .devspace/logs/build.log): %v", err)
+		return
+	}
+
+	log.Donef("Rebuilt %s", strings.Join(imageNames, ", "))
+
+	cmd.redeploy()
+}
+
+// redeploy deploys the chart again and attaches the sync and the port forwardings to the new pod.
+// The terminal waits until the redeploy is done. The deploy output is written to .devspace/logs/deploy.log,
+// because the terminal is attached. Paused syncs stay paused
+func (cmd *UpCmd) redeploy() {
+	cmd.reloadMutex.Lock()
+	cmd.reloadDone = make(chan bool)
+	syncConfigs := cmd.syncConfigs
+	portForwardings := cmd.portForwardings
+	cmd.reloadMutex.Unlock()
+
+	defer func() {
+		cmd.reloadMutex.Lock()
+		close(cmd.reloadDone)
+		cmd.reloadDone = nil
+		cmd.reloadMutex.Unlock()
+	}()
+
+	// The old pod is replaced, so its syncs and port forwardings are stopped first
+	paused := make(map[string]bool)
+	for _, syncConfig := range syncConfigs {
+		paused[getSyncKey(syncConfig)] = syncConfig.IsPaused()
+		syncConfig.Stop()
+	}
+	for _, portForwarding := range portForwardings {
+		portForwarding.Stop()
+	}
+
+	deployErr := cmd.deployChart(log.GetFileLogger("deploy"))
+	if deployErr != nil {
+		log.Errorf("Redeploying failed (see .devspace/logs/deploy.log): %v", deployErr)
+	}
+
+	// The services are restarted even if the deployment failed, because the old pod may still be running
+	if cmd.flags.portforwarding {
+		portForwardings = cmd.startPortForwarding(portForwardings)
+	}
+	if cmd.flags.sync {
+		syncConfigs = cmd.startSync(paused)
+	}
+
+	cmd.reloadMutex.Lock()
+	defer cmd.reloadMutex.Unlock()
+
+	cmd.portForwardings = portForwardings
+	cmd.syncConfigs = syncConfigs
+
+	if cmd.controlServer != nil {
+		cmd.controlServer.SetSyncConfigs(syncConfigs)
+	}
+	if deployErr == nil {
+		cmd.reloadCount++
+	}
+}
+
+// waitForReload waits for a running redeploy and returns true if the pod was replaced since reloadCount
+// was returned by getPod
+func (cmd *UpCmd) waitForReload(reloadCount int) bool {
+	cmd.reloadMutex.Lock()
+	reloadDone := cmd.reloadDone
+	cmd.reloadMutex.Unlock()
+
+	if reloadDone != nil {
+		<-reloadDone
+	}
+
+	cmd.reloadMutex.Lock()
+	defer cmd.reloadMutex.Unlock()
+
+	return reloadDone != nil || cmd.reloadCount != reloadCount
+}
+
+// stopServices stops the current syncs, port forwardings and the sync control server
+func (cmd *UpCmd) stopServices() {
+	cmd.reloadMutex.Lock()
+	defer cmd.reloadMutex.Unlock()
+
+	for _, portForwarding := range cmd.portForwardings {
+		portForwarding.Stop()
+	}
+	for _, syncConfig := range cmd.syncConfigs {
+		syncConfig.Stop()
+	}
+
+	if cmd.controlServer != nil {
+		cmd.controlServer.Stop()
+	}
+}
+
+func (cmd *UpCmd) getPod() (*k8sv1.Pod, int) {
+	cmd.reloadMutex.Lock()
+	defer cmd.reloadMutex.Unlock()
+
+	return cmd.pod, cmd.reloadCount
+}
+
+func (cmd *UpCmd) setPod(pod *k8sv1.Pod) {
+	cmd.reloadMutex.Lock()
+	defer cmd.reloadMutex.Unlock()
+
+	cmd.pod = pod
+}
+
+// keepLocalPorts reuses the automatically chosen local ports of the previous ports, so that a restarted
+// port forwarding is reachable on the same ports
+func keepLocalPorts(ports []*portforward.Port, previousPorts []*portforward.Port) {
+	if len(ports) != len(previousPorts) {
+		return
+	}
+
+	for i, port := range ports {
+		if port.LocalPort == 0 && port.RemotePort == previousPorts[i].RemotePort {
+			port.LocalPort = previousPorts[i].LocalPort
+		}
+	}
+}
@@ -0,0 +1,312 @@
+package buildkit
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/covexo/devspace/pkg/devspace/builder"
+	"github.com/covexo/devspace/pkg/devspace/builder/docker"
+	"github.com/covexo/devspace/pkg/devspace/clients/kubectl"
+	"github.com/covexo/devspace/pkg/devspace/config/v1"
+	"github.com/covexo/devspace/pkg/devspace/registry"
+	"github.com/covexo/devspace/pkg/util/log"
+	"github.com/covexo/devspace/pkg/util/processutil"
+	"github.com/covexo/devspace/pkg/util/randutil"
+	"github.com/docker/docker/api/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Builder holds the necessary information to build and push docker images with BuildKit
+type Builder struct {
+	RegistryURL    string
+	ImageName      string
+	ImageTag       string
+	BuildNamespace string
+
+	allowInsecureRegistry bool
+	digest                string
+	config                *v1.BuildkitBuildEngine
+	kubectl               *kubernetes.Clientset
+	log                   log.Logger
+}
+
+// buildScript unpacks the build context from stdin into the directory $0 and runs buildctl with the
+// remaining arguments. The directory is removed afterwards, because the BuildKit pod is reused
+const buildScript = `set -e; trap 'rm -rf "$0"' EXIT; mkdir -p "$0"; cd "$0"; tar xzf -; buildctl build "$@"`
+
+// manifestDigestRegEx matches the progress line in which buildctl reports the digest of the exported manifest
+var manifestDigestRegEx = regexp.MustCompile(`exporting manifest (sha256:[a-f0-9]{64})`)
+
+// NewBuilder creates a new buildkit.Builder instance
+func NewBuilder(registryURL, imageName, imageTag, buildNamespace string, config *v1.BuildkitBuildEngine, kubectl *kubernetes.Clientset, allowInsecureRegistry bool, log log.Logger) (*Builder, error) {
+	if config == nil {
+		config = &v1.BuildkitBuildEngine{}
+	}
+
+	return &Builder{
+		RegistryURL:           registryURL,
+		ImageName:             imageName,
+		ImageTag:              imageTag,
+		BuildNamespace:        buildNamespace,
+		allowInsecureRegistry: allowInsecureRegistry,
+		config:                config,
+		kubectl:               kubectl,
+		log:                   log,
+	}, nil
+}
+
+// Authenticate authenticates BuildKit for pushing to the RegistryURL (if username == "", it will try to get login data from local docker daemon)
+func (b *Builder) Authenticate(username, password string, checkCredentialsStore bool) (*types.AuthConfig, error) {
+	email := "noreply@devspace-cloud.com"
+
+	if len(username) == 0 {
+		dockerBuilder, dockerBuilderErr := docker.NewBuilder(b.RegistryURL, b.ImageName, b.ImageTag, false, b.log)
+		if dockerBuilderErr != nil {
+			return nil, dockerBuilderErr
+		}
+
+		authConfig, err := dockerBuilder.Authenticate(username, password, true)
+		if err != nil {
+			return nil, err
+		}
+		username = authConfig.Username
+		email = authConfig.Email
+
+		if authConfig.Password != "" {
+			password = authConfig.Password
+		} else {
+			password = authConfig.IdentityToken
+		}
+	}
+	return nil, registry.CreatePullSecret(b.kubectl, b.BuildNamespace, b.RegistryURL, username, password, email)
+}
+
+// BuildImage builds and pushes a dockerimage with buildctl within the BuildKit pod
+func (b *Builder) BuildImage(contextPath, dockerfilePath string, options *types.ImageBuildOptions, secrets *builder.BuildSecrets) error {
+	if options == nil {
+		options = &types.ImageBuildOptions{}
+	}
+
+	err := validateOptions(options)
+	if err != nil {
+		return err
+	}
+	if secrets.IsEmpty() == false {
+		return fmt.Errorf("The buildkit build engine does not support secrets and ssh forwarding (use the docker or kaniko engine instead)")
+	}
+
+	contextDockerfilePath, err := builder.GetContextDockerfilePath(contextPath, dockerfilePath)
+	if err != nil {
+		return err
+	}
+
+	buildPod, err := b.getBuildkitPod()
+	if err != nil {
+		return err
+	}
+
+	b.log.Done("BuildKit pod " + buildPod.Name + " is ready")
+
+	randString, _ := randutil.GenerateRandomString(12)
+	buildDir := "/tmp/devspace-build-" + strings.ToLower(randString)
+	buildCmd := append([]string{"sh", "-c", buildScript, buildDir}, b.getBuildctlArgs(b.getImageDestination(), contextDockerfilePath, options)...)
+
+	b.log.StartWait("Building container image")
+	defer b.log.StopWait()
+
+	exitChannel := make(chan error)
+
+	stdin, stdout, stderr, err := kubectl.Exec(b.kubectl, buildPod, buildkitContainerName, buildCmd, false, exitChannel)
+	if err != nil {
+		return fmt.Errorf("Failed to start image building: %v", err)
+	}
+
+	// The context is streamed while the output is read, the build starts after stdin is closed
+	uploadErrChan := make(chan error, 1)
+
+	go func() {
+		uploadErr := builder.WriteContextArchive(stdin, contextPath, dockerfilePath)
+		stdin.Close()
+
+		uploadErrChan <- uploadErr
+	}()
+
+	lastLine := b.printOutput(stdout, stderr)
+	exitError := <-exitChannel
+
+	// If buildctl failed before it read the whole context, nothing reads stdin anymore and the upload
+	// would block forever. Closing stdin stops it, the upload error only matters if the build succeeded
+	stdin.Close()
+
+	if exitError != nil {
+		return fmt.Errorf("Error: %v, Last BuildKit Output: %s", exitError, lastLine)
+	}
+
+	uploadErr := <-uploadErrChan
+	if uploadErr != nil {
+		return fmt.Errorf("Error uploading build context: %v", uploadErr)
+	}
+
+	b.log.Done("Done building image")
+
+	return nil
+}
+
+func (b *Builder) getImageDestination() string {
+	imageDestination := b.ImageName + ":" + b.ImageTag
+
+	if b.RegistryURL != "" {
+		imageDestination = strings.TrimSuffix(b.RegistryURL, "/") + "/" + imageDestination
+	}
+
+	return imageDestination
+}
+
+// getBuildctlArgs returns the arguments for buildctl build. The context is unpacked into the working directory
+func (b *Builder) getBuildctlArgs(imageDestination, contextDockerfilePath string, options *types.ImageBuildOptions) []string {
+	output := "type=image,name=" + imageDestination + ",push=true"
+	if b.allowInsecureRegistry {
+		output += ",registry.insecure=true"
+	}
+
+	args := []string{
+		"--progress=plain",
+		"--frontend=dockerfile.v0",
+		"--local=context=.",
+		"--local=dockerfile=" + path.Dir(contextDockerfilePath),
+		"--opt=filename=" + path.Base(contextDockerfilePath),
+		"--output=" + output,
+	}
+
+	// Build args and labels are sorted, so that the arguments are stable
+	buildArgKeys := make([]string, 0, len(options.BuildArgs))
+	for key := range options.BuildArgs {
+		buildArgKeys = append(buildArgKeys, key)
+	}
+
+	sort.Strings(buildArgKeys)
+
+	for _, key := range buildArgKeys {
+		if options.BuildArgs[key] != nil {
+			args = append(args, "--opt=build-arg:"+key+"="+*options.BuildArgs[key])
+		}
+	}
+
+	labelKeys := make([]string, 0, len(options.Labels))
+	for key := range options.Labels {
+		labelKeys = append(labelKeys, key)
+	}
+
+	sort.Strings(labelKeys)
+
+	for _, key := range labelKeys {
+		args = append(args, "--opt=label:"+key+"="+options.Labels[key])
+	}
+
+	if options.Target != "" {
+		args = append(args, "--opt=target="+options.Target)
+	}
+	if len(options.ExtraHosts) > 0 {
+		args = append(args, "--opt=add-hosts="+getAddHosts(options.ExtraHosts))
+	}
+	if options.PullParent {
+		args = append(args, "--opt=image-resolve-mode=pull")
+	}
+	if options.NoCache {
+		args = append(args, "--no-cache")
+	}
+
+	for _, cacheFrom := range options.CacheFrom {
+		args = append(args, "--import-cache=type=registry,ref="+cacheFrom)
+	}
+
+	// The registry cache contains all layers of multi-stage builds
+	cacheRepo := GetCacheRepo(strings.TrimSuffix(imageDestination, ":"+b.ImageTag), b.config)
+	if cacheRepo != "" {
+		args = append(args, "--export-cache=type=registry,mode=max,ref="+cacheRepo, "--import-cache=type=registry,ref="+cacheRepo)
+	}
+
+	return args
+}
+
+// GetCacheRepo returns the image reference the registry cache of the image repository is pushed to or an
+// empty string if the cache is disabled. By default, the cache is the tag buildcache of the image repository
+func GetCacheRepo(imageRepository string, config *v1.BuildkitBuildEngine) string {
+	if config == nil || config.Cache == nil || *config.Cache == false {
+		return ""
+	}
+	if config.CacheRepo != nil && *config.CacheRepo != "" {
+		return *config.CacheRepo
+	}
+
+	return imageRepository + ":buildcache"
+}
+
+// getAddHosts converts the extra hosts from the docker format host:ip into the format host=ip of the
+// dockerfile frontend. Only the first colon separates the host, because IPv6 addresses contain colons
+func getAddHosts(extraHosts []string) string {
+	addHosts := make([]string, 0, len(extraHosts))
+	for _, extraHost := range extraHosts {
+		addHosts = append(addHosts, strings.Replace(extraHost, ":", "=", 1))
+	}
+
+	return strings.Join(addHosts, ",")
+}
+
+// printOutput prints the plain progress output of buildctl and returns the last line
+func (b *Builder) printOutput(stdout, stderr io.ReadCloser) string {
+	wg := &sync.WaitGroup{}
+	outputMutex := sync.Mutex{}
+	lastLine := ""
+
+	printLine := func(line string) {
+		if strings.TrimSpace(line) == "" {
+			return
+		}
+
+		outputMutex.Lock()
+		defer outputMutex.Unlock()
+
+		b.log.Info("build > " + line)
+		lastLine = line
+
+		if match := manifestDigestRegEx.FindStringSubmatch(line); match != nil {
+			b.digest = match[1]
+		}
+	}
+
+	processutil.RunOnEveryLine(stdout, printLine, 500, wg)
+	processutil.RunOnEveryLine(stderr, printLine, 500, wg)
+
+	wg.Wait()
+
+	return lastLine
+}
+
+// validateOptions returns an error for build options that BuildKit does not support
+func validateOptions(options *types.ImageBuildOptions) error {
+	unsupported := []string{}
+
+	if options.NetworkMode != "" {
+		unsupported = append(unsupported, "network")
+	}
+	if options.Squash {
+		unsupported = append(unsupported, "squash")
+	}
+
+	if len(unsupported) > 0 {
+		return fmt.Errorf("The buildkit build engine does not support the build options: %s", strings.Join(unsupported, ", "))
+	}
+
+	return nil
+}
+
+// PushImage only returns the digest of the manifest, because buildctl already pushes the image
+func (b *Builder) PushImage() (string, error) {
+	return b.digest, nil
+}
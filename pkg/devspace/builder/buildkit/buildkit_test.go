@@ -0,0 +1,82 @@
+package buildkit
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/covexo/devspace/pkg/devspace/config/v1"
+	"github.com/docker/docker/api/types"
+)
+
+func TestGetBuildctlArgs(t *testing.T) {
+	buildArg := "1.0"
+	cache := true
+	cacheRepo := "registry.example.com/user/cache:app"
+
+	defaultArgs := []string{
+		"--progress=plain",
+		"--frontend=dockerfile.v0",
+		"--local=context=.",
+		"--local=dockerfile=.",
+		"--opt=filename=Dockerfile",
+		"--output=type=image,name=registry.example.com/user/app:abc,push=true",
+	}
+
+	testCases := map[string]struct {
+		builder      *Builder
+		options      *types.ImageBuildOptions
+		expectedArgs []string
+	}{
+		"no options": {
+			builder:      &Builder{ImageTag: "abc", config: &v1.BuildkitBuildEngine{}},
+			options:      &types.ImageBuildOptions{},
+			expectedArgs: defaultArgs,
+		},
+		"build options": {
+			builder: &Builder{ImageTag: "abc", config: &v1.BuildkitBuildEngine{}},
+			options: &types.ImageBuildOptions{
+				BuildArgs:  map[string]*string{"VERSION": &buildArg, "UNSET": nil},
+				Labels:     map[string]string{"b": "2", "a": "1"},
+				Target:     "dev",
+				ExtraHosts: []string{"db:10.0.0.1", "ipv6:fe80::1"},
+				PullParent: true,
+				NoCache:    true,
+				CacheFrom:  []string{"user/app:latest"},
+			},
+			expectedArgs: append(append([]string{}, defaultArgs...),
+				"--opt=build-arg:VERSION=1.0",
+				"--opt=label:a=1",
+				"--opt=label:b=2",
+				"--opt=target=dev",
+				"--opt=add-hosts=db=10.0.0.1,ipv6=fe80::1",
+				"--opt=image-resolve-mode=pull",
+				"--no-cache",
+				"--import-cache=type=registry,ref=user/app:latest",
+			),
+		},
+		"insecure registry with default cache repo": {
+			builder: &Builder{ImageTag: "abc", allowInsecureRegistry: true, config: &v1.BuildkitBuildEngine{Cache: &cache}},
+			options: &types.ImageBuildOptions{},
+			expectedArgs: append(append([]string{}, defaultArgs[:5]...),
+				"--output=type=image,name=registry.example.com/user/app:abc,push=true,registry.insecure=true",
+				"--export-cache=type=registry,mode=max,ref=registry.example.com/user/app:buildcache",
+				"--import-cache=type=registry,ref=registry.example.com/user/app:buildcache",
+			),
+		},
+		"configured cache repo": {
+			builder: &Builder{ImageTag: "abc", config: &v1.BuildkitBuildEngine{Cache: &cache, CacheRepo: &cacheRepo}},
+			options: &types.ImageBuildOptions{},
+			expectedArgs: append(append([]string{}, defaultArgs...),
+				"--export-cache=type=registry,mode=max,ref="+cacheRepo,
+				"--import-cache=type=registry,ref="+cacheRepo,
+			),
+		},
+	}
+
+	for testName, testCase := range testCases {
+		args := testCase.builder.getBuildctlArgs("registry.example.com/user/app:abc", "Dockerfile", testCase.options)
+		if reflect.DeepEqual(args, testCase.expectedArgs) == false {
+			t.Errorf("%s: expected %v, got %v", testName, testCase.expectedArgs, args)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+package buildkit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/covexo/devspace/pkg/devspace/clients/kubectl"
+	"github.com/covexo/devspace/pkg/devspace/registry"
+	k8sv1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// buildkitImage is the rootless BuildKit image, which contains buildkitd, buildctl and busybox
+const buildkitImage = "moby/buildkit:v0.6.4-rootless"
+
+// buildkitContainerName is the name of the container buildctl is executed in
+const buildkitContainerName = "buildkit"
+
+// dockerConfigPath is where the pull secret is mounted, buildctl reads the registry credentials from there
+const dockerConfigPath = "/home/user/.docker"
+
+// rootlessUser is the user the rootless BuildKit image runs as
+const rootlessUser = int64(1000)
+
+// PodLabel is set on all BuildKit pods, so that they can be removed with DeletePods
+const PodLabel = "devspace-buildkit"
+
+// specHashAnnotation holds the hash of the spec a BuildKit pod was created from
+const specHashAnnotation = "devspace-buildkit-spec-hash"
+
+const podStartTimeout = 2 * 60 * time.Second
+const podReadyCheckInterval = 2 * time.Second
+
+// getBuildkitPod returns the running BuildKit pod and creates it if necessary. The pod is shared between
+// builds to the same registry, so that buildkitd keeps its local cache. A pod that was created from a
+// different configuration (e.g. another image) is deleted and created again
+func (b *Builder) getBuildkitPod() (*k8sv1.Pod, error) {
+	podSpec := b.getBuildkitPodSpec()
+	pods := b.kubectl.Core().Pods(b.BuildNamespace)
+
+	pod, err := pods.Get(podSpec.Name, metav1.GetOptions{})
+	if err == nil && pod.Annotations[specHashAnnotation] != podSpec.Annotations[specHashAnnotation] {
+		err = b.deleteBuildkitPod(pod.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		pod, err = pods.Get(podSpec.Name, metav1.GetOptions{})
+	}
+
+	if err != nil {
+		if k8serrors.IsNotFound(err) == false {
+			return nil, fmt.Errorf("Unable to get BuildKit pod: %v", err)
+		}
+
+		b.log.StartWait("Creating BuildKit pod")
+		pod, err = pods.Create(podSpec)
+		b.log.StopWait()
+
+		// Another build may have created the pod in the meantime
+		if err != nil && k8serrors.IsAlreadyExists(err) == false {
+			return nil, fmt.Errorf("Unable to create BuildKit pod: %v", err)
+		}
+	}
+
+	if pod != nil && kubectl.IsPodReady(pod) {
+		return pod, nil
+	}
+
+	b.log.StartWait("Waiting for BuildKit pod to start")
+	defer b.log.StopWait()
+
+	for waitTime := podStartTimeout; waitTime > 0; waitTime -= podReadyCheckInterval {
+		pod, err = pods.Get(podSpec.Name, metav1.GetOptions{})
+		if err == nil && kubectl.IsPodReady(pod) {
+			return pod, nil
+		}
+
+		time.Sleep(podReadyCheckInterval)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("BuildKit pod %s didn't start within %s: %v", podSpec.Name, podStartTimeout.String(), err)
+	}
+
+	return nil, fmt.Errorf("BuildKit pod %s didn't start within %s: %s", podSpec.Name, podStartTimeout.String(), kubectl.GetPodStatus(pod))
+}
+
+// deleteBuildkitPod deletes the outdated pod and waits until it is gone, so that it can be created again
+func (b *Builder) deleteBuildkitPod(name string) error {
+	pods := b.kubectl.Core().Pods(b.BuildNamespace)
+
+	b.log.StartWait("Recreating BuildKit pod, because its configuration changed")
+	defer b.log.StopWait()
+
+	gracePeriod := int64(3)
+
+	err := pods.Delete(name, &metav1.DeleteOptions{
+		GracePeriodSeconds: &gracePeriod,
+	})
+	if err != nil && k8serrors.IsNotFound(err) == false {
+		return fmt.Errorf("Unable to delete outdated BuildKit pod %s: %v", name, err)
+	}
+
+	for waitTime := podStartTimeout; waitTime > 0; waitTime -= podReadyCheckInterval {
+		_, err = pods.Get(name, metav1.GetOptions{})
+		if k8serrors.IsNotFound(err) {
+			return nil
+		}
+
+		time.Sleep(podReadyCheckInterval)
+	}
+
+	return fmt.Errorf("Outdated BuildKit pod %s wasn't deleted within %s", name, podStartTimeout.String())
+}
+
+// DeletePods deletes all BuildKit pods in the namespace
+func DeletePods(kubectlClient *kubernetes.Clientset, namespace string) error {
+	return kubectlClient.Core().Pods(namespace).DeleteCollection(&metav1.DeleteOptions{}, metav1.ListOptions{
+		LabelSelector: PodLabel + "=true",
+	})
+}
+
+// getSpecHash returns the hash of everything the pod is created from
+func getSpecHash(pod *k8sv1.Pod) string {
+	data, _ := json.Marshal([]interface{}{pod.Annotations, pod.Spec})
+	hash := sha256.Sum256(data)
+
+	return hex.EncodeToString(hash[:])
+}
+
+// getBuildkitPodSpec returns a pod running a rootless buildkitd. If an address is configured, the pod only
+// runs buildctl against the existing buildkitd
+func (b *Builder) getBuildkitPodSpec() *k8sv1.Pod {
+	pullSecretName := registry.GetRegistryAuthSecretName(b.RegistryURL)
+	runAsUser := rootlessUser
+
+	image := buildkitImage
+	if b.config.Image != nil && *b.config.Image != "" {
+		image = *b.config.Image
+	}
+
+	address := ""
+	if b.config.Address != nil {
+		address = *b.config.Address
+	}
+
+	nameHash := sha256.Sum256([]byte(pullSecretName))
+
+	container := k8sv1.Container{
+		Name:            buildkitContainerName,
+		Image:           image,
+		ImagePullPolicy: k8sv1.PullIfNotPresent,
+		Env: []k8sv1.EnvVar{
+			{
+				Name:  "DOCKER_CONFIG",
+				Value: dockerConfigPath,
+			},
+		},
+		SecurityContext: &k8sv1.SecurityContext{
+			RunAsUser: &runAsUser,
+		},
+		VolumeMounts: []k8sv1.VolumeMount{
+			{
+				Name:      pullSecretName,
+				MountPath: dockerConfigPath,
+			},
+		},
+	}
+
+	annotations := map[string]string{}
+
+	if address != "" {
+		container.Command = []string{"sleep"}
+		container.Args = []string{"infinity"}
+		container.Env = append(container.Env, k8sv1.EnvVar{
+			Name:  "BUILDKIT_HOST",
+			Value: address,
+		})
+	} else {
+		// Rootless buildkitd can't create its own process sandbox within an unprivileged pod
+		container.Args = []string{"--oci-worker-no-process-sandbox"}
+		container.ReadinessProbe = &k8sv1.Probe{
+			Handler: k8sv1.Handler{
+				Exec: &k8sv1.ExecAction{
+					Command: []string{"buildctl", "debug", "workers"},
+				},
+			},
+			InitialDelaySeconds: 2,
+			PeriodSeconds:       2,
+		}
+
+		annotations["container.apparmor.security.beta.kubernetes.io/"+buildkitContainerName] = "unconfined"
+		annotations["container.seccomp.security.alpha.kubernetes.io/"+buildkitContainerName] = "unconfined"
+	}
+
+	pod := &k8sv1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "devspace-buildkit-" + hex.EncodeToString(nameHash[:])[:10],
+			Labels: map[string]string{
+				PodLabel: "true",
+			},
+			Annotations: annotations,
+		},
+		Spec: k8sv1.PodSpec{
+			Containers: []k8sv1.Container{container},
+			Volumes: []k8sv1.Volume{
+				{
+					Name: pullSecretName,
+					VolumeSource: k8sv1.VolumeSource{
+						Secret: &k8sv1.SecretVolumeSource{
+							SecretName: pullSecretName,
+							Items: []k8sv1.KeyToPath{
+								{
+									Key:  k8sv1.DockerConfigJsonKey,
+									Path: "config.json",
+								},
+							},
+						},
+					},
+				},
+			},
+			RestartPolicy: k8sv1.RestartPolicyAlways,
+		},
+	}
+
+	pod.Annotations[specHashAnnotation] = getSpecHash(pod)
+
+	return pod
+}
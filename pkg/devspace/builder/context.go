@@ -0,0 +1,173 @@
+package builder
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/covexo/devspace/pkg/util/ignoreutil"
+	gitignore "github.com/sabhiram/go-gitignore"
+)
+
+// ignoredContextPaths are never part of the build context, because the devspace config
+// and logs within the context change on every run
+var ignoredContextPaths = []string{".devspace/"}
+
+// externalDockerfileDir is the directory within the context archive that holds a Dockerfile located
+// outside of the context. It can't collide with the context files, because .devspace/ is never sent
+const externalDockerfileDir = ".devspace/"
+
+// WalkContextFunc is called for every file and directory of the build context with the
+// absolute path and the slash separated path relative to the context
+type WalkContextFunc func(path, relativePath string, info os.FileInfo) error
+
+// WalkContext walks the build context in lexical order and skips all files that are
+// excluded by a .dockerignore file
+func WalkContext(contextPath string, walkFn WalkContextFunc) error {
+	ignoreRules, err := ignoreutil.GetIgnoreRules(contextPath)
+	if err != nil {
+		return fmt.Errorf("Unable to parse .dockerignore files: %v", err)
+	}
+
+	ignoreMatcher, err := gitignore.CompileIgnoreLines(append(ignoreRules, ignoredContextPaths...)...)
+	if err != nil {
+		return fmt.Errorf("Unable to parse .dockerignore files: %v", err)
+	}
+
+	return filepath.Walk(contextPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relativePath, err := filepath.Rel(contextPath, path)
+		if err != nil {
+			return err
+		}
+
+		if relativePath == "." {
+			return nil
+		}
+
+		relativePath = filepath.ToSlash(relativePath)
+
+		if info.IsDir() {
+			if ignoreMatcher.MatchesPath(relativePath + "/") {
+				return filepath.SkipDir
+			}
+		} else if ignoreMatcher.MatchesPath(relativePath) {
+			return nil
+		}
+
+		return walkFn(path, relativePath, info)
+	})
+}
+
+// GetContextDockerfilePath returns the slash separated path of the Dockerfile within the archive
+// written by WriteContextArchive
+func GetContextDockerfilePath(contextPath, dockerfilePath string) (string, error) {
+	relativePath, err := filepath.Rel(contextPath, dockerfilePath)
+	if err != nil {
+		return "", err
+	}
+
+	relativePath = filepath.ToSlash(relativePath)
+	if relativePath == ".." || strings.HasPrefix(relativePath, "../") {
+		return externalDockerfileDir + filepath.Base(dockerfilePath), nil
+	}
+
+	return relativePath, nil
+}
+
+// WriteContextArchive writes the build context as gzip compressed tar to writer. Files excluded by a
+// .dockerignore are skipped. The Dockerfile is always added at GetContextDockerfilePath, even if it is
+// ignored or outside of the context
+func WriteContextArchive(writer io.Writer, contextPath, dockerfilePath string) error {
+	contextDockerfilePath, err := GetContextDockerfilePath(contextPath, dockerfilePath)
+	if err != nil {
+		return err
+	}
+
+	gzipWriter := gzip.NewWriter(writer)
+	tarWriter := tar.NewWriter(gzipWriter)
+	dockerfileWritten := false
+
+	err = WalkContext(contextPath, func(path, relativePath string, info os.FileInfo) error {
+		if relativePath == contextDockerfilePath {
+			dockerfileWritten = true
+		}
+
+		return writeArchiveEntry(tarWriter, path, relativePath, info)
+	})
+	if err != nil {
+		return err
+	}
+
+	if dockerfileWritten == false {
+		info, err := os.Stat(dockerfilePath)
+		if err != nil {
+			return err
+		}
+
+		err = writeArchiveEntry(tarWriter, dockerfilePath, contextDockerfilePath, info)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = tarWriter.Close()
+	if err != nil {
+		return err
+	}
+
+	return gzipWriter.Close()
+}
+
+func writeArchiveEntry(tarWriter *tar.Writer, path, archivePath string, info os.FileInfo) error {
+	link := ""
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(path)
+		if err != nil {
+			return err
+		}
+
+		link = target
+	}
+
+	header, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return err
+	}
+
+	// Files in the image belong to root like with docker build
+	header.Name = archivePath
+	header.Uid, header.Gid = 0, 0
+	header.Uname, header.Gname = "", ""
+
+	if info.IsDir() {
+		header.Name += "/"
+	}
+
+	err = tarWriter.WriteHeader(header)
+	if err != nil {
+		return err
+	}
+
+	if info.Mode().IsRegular() == false {
+		return nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	// Only the size from the header may be written, even if the file grows in the meantime
+	_, err = io.CopyN(tarWriter, file, header.Size)
+	return err
+}
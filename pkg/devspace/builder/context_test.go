@@ -0,0 +1,120 @@
+package builder
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteContextArchive(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "devspace-context")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(tempDir)
+
+	contextPath := filepath.Join(tempDir, "context")
+	files := map[string]string{
+		"context/Dockerfile":              "FROM alpine",
+		"context/src/index.js":            "console.log('hello')",
+		"context/node_modules/a/index.js": "module.exports = 1",
+		"context/.dockerignore":           "node_modules",
+		"context/.devspace/config.yaml":   "version: v1",
+		"build/Dockerfile.dev":            "FROM node",
+	}
+
+	for path, content := range files {
+		writeFile(t, filepath.Join(tempDir, path), content)
+	}
+
+	testCases := []struct {
+		dockerfilePath            string
+		expectedDockerfile        string
+		expectedDockerfileContent string
+	}{
+		{
+			dockerfilePath:            filepath.Join(contextPath, "Dockerfile"),
+			expectedDockerfile:        "Dockerfile",
+			expectedDockerfileContent: "FROM alpine",
+		},
+		{
+			dockerfilePath:            filepath.Join(tempDir, "build", "Dockerfile.dev"),
+			expectedDockerfile:        ".devspace/Dockerfile.dev",
+			expectedDockerfileContent: "FROM node",
+		},
+	}
+
+	for _, testCase := range testCases {
+		contextDockerfilePath, err := GetContextDockerfilePath(contextPath, testCase.dockerfilePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if contextDockerfilePath != testCase.expectedDockerfile {
+			t.Fatalf("Expected Dockerfile path %s, got %s", testCase.expectedDockerfile, contextDockerfilePath)
+		}
+
+		archive := &bytes.Buffer{}
+
+		err = WriteContextArchive(archive, contextPath, testCase.dockerfilePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		entries := readArchive(t, archive)
+
+		for _, path := range []string{"src/", "src/index.js", ".dockerignore", "Dockerfile"} {
+			if _, ok := entries[path]; ok == false {
+				t.Fatalf("Expected %s in archive", path)
+			}
+		}
+
+		for _, path := range []string{"node_modules/", "node_modules/a/index.js", ".devspace/config.yaml"} {
+			if _, ok := entries[path]; ok {
+				t.Fatalf("Expected %s to be excluded from archive", path)
+			}
+		}
+
+		if entries[testCase.expectedDockerfile] != testCase.expectedDockerfileContent {
+			t.Fatalf("Expected %s with content %s, got %s", testCase.expectedDockerfile, testCase.expectedDockerfileContent, entries[testCase.expectedDockerfile])
+		}
+	}
+}
+
+func readArchive(t *testing.T, archive io.Reader) map[string]string {
+	gzipReader, err := gzip.NewReader(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tarReader := tar.NewReader(gzipReader)
+	entries := map[string]string{}
+
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+
+		if header.Uid != 0 || header.Gid != 0 {
+			t.Fatalf("Expected %s to belong to root", header.Name)
+		}
+
+		content, err := ioutil.ReadAll(tarReader)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		entries[header.Name] = string(content)
+	}
+
+	return entries
+}
@@ -16,6 +16,45 @@ import (
 	"github.com/docker/go-connections/tlsconfig"
 )
 
+// localClusterContexts are the kube contexts of clusters that run on the local docker daemon
+var localClusterContexts = []string{"docker-desktop", "docker-for-desktop"}
+
+// SharesClusterDaemon returns true if a builder with these options builds the images with the docker daemon
+// of the cluster node, so that the cluster can use them without pushing them to a registry. localContexts
+// are additional kube contexts of clusters that run on the local docker daemon
+func SharesClusterDaemon(preferMinikube bool, localContexts []string) bool {
+	// NewBuilder falls back to the local docker daemon if the client for minikube can't be created
+	if preferMinikube {
+		_, _, err := newDockerClientFromMinikube()
+		if err == nil {
+			return true
+		}
+	}
+
+	return isLocalContext(localContexts)
+}
+
+// SharesClusterDaemon returns true if the builder is connected to the docker daemon of the cluster node
+func (b *Builder) SharesClusterDaemon(localContexts []string) bool {
+	return b.minikubeEnv != nil || isLocalContext(localContexts)
+}
+
+// isLocalContext returns true if the current kube context belongs to a cluster on the local docker daemon
+func isLocalContext(localContexts []string) bool {
+	currentContext, err := kubectl.GetCurrentContext()
+	if err != nil {
+		return false
+	}
+
+	for _, localContext := range append(localClusterContexts, localContexts...) {
+		if currentContext == localContext {
+			return true
+		}
+	}
+
+	return false
+}
+
 func newDockerClientFromEnvironment() (client.CommonAPIClient, error) {
 	cli, err := client.NewClientWithOpts(client.FromEnv)
 	if err != nil {
@@ -26,14 +65,16 @@ func newDockerClientFromEnvironment() (client.CommonAPIClient, error) {
 	return cli, nil
 }
 
-func newDockerClientFromMinikube() (client.CommonAPIClient, error) {
+// newDockerClientFromMinikube returns a client for the docker daemon of minikube and the environment
+// variables of this daemon (e.g. DOCKER_HOST)
+func newDockerClientFromMinikube() (client.CommonAPIClient, map[string]string, error) {
 	if kubectl.IsMinikube() == false {
-		return nil, errors.New("Cluster is not a minikube cluster")
+		return nil, nil, errors.New("Cluster is not a minikube cluster")
 	}
 
 	env, err := getMinikubeEnvironment()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	var httpclient *http.Client
@@ -46,7 +87,7 @@ func newDockerClientFromMinikube() (client.CommonAPIClient, error) {
 		}
 		tlsc, err := tlsconfig.Client(options)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
 		httpclient = &http.Client{
@@ -66,7 +107,12 @@ func newDockerClientFromMinikube() (client.CommonAPIClient, error) {
 		version = api.DefaultVersion
 	}
 
-	return client.NewClient(host, version, httpclient, nil)
+	cli, err := client.NewClient(host, version, httpclient, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return cli, env, nil
 }
 
 func getMinikubeEnvironment() (map[string]string, error) {
@@ -9,6 +9,7 @@ import (
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/pkg/homedir"
+	"github.com/docker/docker/registry"
 )
 
 const dockerFileFolder = ".docker"
@@ -33,3 +34,24 @@ func getAllAuthConfigs() (map[string]types.AuthConfig, error) {
 
 	return config.GetAllCredentials()
 }
+
+// GetRegistryAuth returns the credentials for the registry from the docker config or its credentials store.
+// An empty registryURL or hub.docker.com returns the credentials for Docker Hub
+func GetRegistryAuth(registryURL string) (*types.AuthConfig, error) {
+	config, err := loadDockerConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	serverAddress := registry.IndexServer
+	if registryURL != "" && registryURL != "hub.docker.com" {
+		serverAddress = registry.ConvertToHostname(registryURL)
+	}
+
+	authConfig, err := config.GetAuthConfig(serverAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	return &authConfig, nil
+}
@@ -1,10 +1,15 @@
 package docker
 
 import (
+	"encoding/json"
+	"io"
 	"strings"
 
 	"context"
 
+	"github.com/covexo/devspace/pkg/devspace/builder"
+	"github.com/covexo/devspace/pkg/util/log"
+
 	"github.com/docker/distribution/reference"
 	"github.com/docker/docker/pkg/term"
 	"github.com/docker/docker/registry"
@@ -36,17 +41,23 @@ type Builder struct {
 	imageURL   string
 	authConfig *types.AuthConfig
 	client     client.CommonAPIClient
+	log        log.Logger
+
+	// minikubeEnv holds the environment of the minikube docker daemon if the client is connected to it
+	minikubeEnv map[string]string
 }
 
 // NewBuilder creates a new docker Builder instance
-func NewBuilder(registryURL, imageName, imageTag string, preferMinikube bool) (*Builder, error) {
+func NewBuilder(registryURL, imageName, imageTag string, preferMinikube bool, log log.Logger) (*Builder, error) {
 	var cli client.CommonAPIClient
+	var minikubeEnv map[string]string
 	var err error
 
 	if preferMinikube {
-		cli, err = newDockerClientFromMinikube()
+		cli, minikubeEnv, err = newDockerClientFromMinikube()
 	}
 	if preferMinikube == false || err != nil {
+		minikubeEnv = nil
 		cli, err = newDockerClientFromEnvironment()
 
 		if err != nil {
@@ -78,19 +89,22 @@ func NewBuilder(registryURL, imageName, imageTag string, preferMinikube bool) (*
 		ImageTag:    imageTag,
 		imageURL:    imageURL,
 		client:      cli,
+		log:         log,
+		minikubeEnv: minikubeEnv,
 	}, nil
 }
 
 // BuildImage builds a dockerimage with the docker cli
 // contextPath is the absolute path to the context path
 // dockerfilePath is the absolute path to the dockerfile WITHIN the contextPath
-func (b *Builder) BuildImage(contextPath, dockerfilePath string, options *types.ImageBuildOptions) error {
+// Secrets and ssh forwarding require BuildKit (Docker 18.09 or newer)
+func (b *Builder) BuildImage(contextPath, dockerfilePath string, options *types.ImageBuildOptions, secrets *builder.BuildSecrets) error {
 	if options == nil {
 		options = &types.ImageBuildOptions{}
 	}
 
 	ctx := context.Background()
-	outStream := command.NewOutStream(stdout)
+	outStream := command.NewOutStream(b.getOutput())
 	contextDir, relDockerfile, err := build.GetContextFromLocalDir(contextPath, dockerfilePath)
 	if err != nil {
 		return err
@@ -124,18 +138,35 @@ func (b *Builder) BuildImage(contextPath, dockerfilePath string, options *types.
 	// Setup an upload progress bar
 	progressOutput := streamformatter.NewProgressOutput(outStream)
 	body := progress.NewProgressReader(buildCtx, progressOutput, 0, "", "Sending build context to Docker daemon")
-	response, err := b.client.ImageBuild(ctx, body, types.ImageBuildOptions{
-		Tags:        []string{b.imageURL},
-		Dockerfile:  relDockerfile,
-		BuildArgs:   options.BuildArgs,
-		AuthConfigs: authConfigs,
-	})
+
+	// All configured options are passed to the daemon, which reports unsupported ones (e.g. squash without experimental features)
+	buildOptions := *options
+	buildOptions.Tags = []string{b.imageURL}
+	buildOptions.Dockerfile = relDockerfile
+	buildOptions.AuthConfigs = authConfigs
+
+	// The daemon requests secrets and the ssh agent through a session, so they never become part of the image
+	// and are never written to disk
+	var auxCallback func(jsonmessage.JSONMessage)
+	if secrets.IsEmpty() == false {
+		buildSession, err := b.startSession(contextPath, secrets)
+		if err != nil {
+			return err
+		}
+		defer buildSession.Close()
+
+		buildOptions.Version = types.BuilderBuildKit
+		buildOptions.SessionID = buildSession.ID()
+		auxCallback = b.getBuildkitProgressCallback()
+	}
+
+	response, err := b.client.ImageBuild(ctx, body, buildOptions)
 	if err != nil {
 		return err
 	}
 	defer response.Body.Close()
 
-	err = jsonmessage.DisplayJSONMessagesStream(response.Body, outStream, outStream.FD(), outStream.IsTerminal(), nil)
+	err = jsonmessage.DisplayJSONMessagesStream(response.Body, outStream, outStream.FD(), outStream.IsTerminal(), auxCallback)
 	if err != nil {
 		return err
 	}
@@ -189,31 +220,54 @@ func (b *Builder) Authenticate(user, password string, checkCredentialsStore bool
 	return b.authConfig, nil
 }
 
-// PushImage pushes an image to the specified registry
-func (b *Builder) PushImage() error {
+// PushImage pushes an image to the specified registry and returns the digest of the pushed manifest
+func (b *Builder) PushImage() (string, error) {
 	ctx := context.Background()
 	ref, err := reference.ParseNormalizedNamed(b.imageURL)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	encodedAuth, err := encodeAuthToBase64(*b.authConfig)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	out, err := b.client.ImagePush(ctx, reference.FamiliarString(ref), types.ImagePushOptions{
 		RegistryAuth: encodedAuth,
 	})
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	outStream := command.NewOutStream(stdout)
-	err = jsonmessage.DisplayJSONMessagesStream(out, outStream, outStream.FD(), outStream.IsTerminal(), nil)
+	// The daemon reports the digest of the pushed manifest as aux message
+	digest := ""
+	auxCallback := func(message jsonmessage.JSONMessage) {
+		if message.Aux == nil {
+			return
+		}
+
+		pushResult := types.PushResult{}
+		if json.Unmarshal(*message.Aux, &pushResult) == nil && pushResult.Digest != "" {
+			digest = pushResult.Digest
+		}
+	}
+
+	outStream := command.NewOutStream(b.getOutput())
+	err = jsonmessage.DisplayJSONMessagesStream(out, outStream, outStream.FD(), outStream.IsTerminal(), auxCallback)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	return nil
+	return digest, nil
+}
+
+// getOutput returns the writer for the build and push output. Progress bars are only shown
+// when writing to the terminal directly, other loggers (e.g. for parallel builds) get plain lines
+func (b *Builder) getOutput() io.Writer {
+	if b.log == nil || b.log == log.GetInstance() {
+		return stdout
+	}
+
+	return b.log
 }
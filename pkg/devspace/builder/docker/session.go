@@ -0,0 +1,109 @@
+package docker
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+
+	"github.com/covexo/devspace/pkg/devspace/builder"
+	"github.com/docker/docker/pkg/jsonmessage"
+	controlapi "github.com/moby/buildkit/api/services/control"
+	"github.com/moby/buildkit/session"
+	"github.com/moby/buildkit/session/secrets"
+	"github.com/moby/buildkit/session/secrets/secretsprovider"
+	"github.com/moby/buildkit/session/sshforward/sshprovider"
+	"github.com/pkg/errors"
+)
+
+// buildkitTraceID is the id of the aux messages that contain the BuildKit progress
+const buildkitTraceID = "moby.buildkit.trace"
+
+// secretStore provides the build secrets to the BuildKit session
+type secretStore struct {
+	secrets map[string][]byte
+}
+
+// GetSecret implements secrets.SecretStore
+func (s *secretStore) GetSecret(ctx context.Context, id string) ([]byte, error) {
+	data, ok := s.secrets[id]
+	if ok == false {
+		return nil, errors.WithStack(secrets.ErrNotFound)
+	}
+
+	return data, nil
+}
+
+// startSession starts a BuildKit session which the daemon uses to request the secrets and the ssh agent
+// during the build. The session has to be closed after the build
+func (b *Builder) startSession(contextPath string, buildSecrets *builder.BuildSecrets) (*session.Session, error) {
+	sharedKey := sha256.Sum256([]byte(contextPath))
+
+	buildSession, err := session.NewSession(context.Background(), filepath.Base(contextPath), hex.EncodeToString(sharedKey[:]))
+	if err != nil {
+		return nil, errors.Errorf("Failed to create BuildKit session: %v", err)
+	}
+
+	if len(buildSecrets.Secrets) > 0 {
+		buildSession.Allow(secretsprovider.NewSecretProvider(&secretStore{
+			secrets: buildSecrets.Secrets,
+		}))
+	}
+
+	if buildSecrets.SSH != nil {
+		sshProvider, err := sshprovider.NewSSHAgentProvider([]sshprovider.AgentConfig{
+			{
+				ID:    "default",
+				Paths: buildSecrets.SSH,
+			},
+		})
+		if err != nil {
+			return nil, errors.Errorf("Failed to forward ssh agent: %v", err)
+		}
+
+		buildSession.Allow(sshProvider)
+	}
+
+	go buildSession.Run(context.Background(), b.client.DialSession)
+
+	return buildSession, nil
+}
+
+// getBuildkitProgressCallback returns an aux callback that prints the steps and the output of a BuildKit build,
+// because the daemon sends them as encoded status messages instead of plain text
+func (b *Builder) getBuildkitProgressCallback() func(jsonmessage.JSONMessage) {
+	printedVertexes := map[string]bool{}
+
+	return func(message jsonmessage.JSONMessage) {
+		if message.ID != buildkitTraceID || message.Aux == nil {
+			return
+		}
+
+		var data []byte
+		if json.Unmarshal(*message.Aux, &data) != nil {
+			return
+		}
+
+		status := &controlapi.StatusResponse{}
+		if status.Unmarshal(data) != nil {
+			return
+		}
+
+		for _, vertex := range status.Vertexes {
+			if vertex.Started == nil || printedVertexes[vertex.Digest.String()] {
+				continue
+			}
+
+			printedVertexes[vertex.Digest.String()] = true
+			b.log.Info(vertex.Name)
+		}
+
+		for _, vertexLog := range status.Logs {
+			for _, line := range strings.Split(strings.TrimRight(string(vertexLog.Msg), "\n"), "\n") {
+				b.log.Info("> " + line)
+			}
+		}
+	}
+}
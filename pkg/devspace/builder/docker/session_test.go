@@ -0,0 +1,31 @@
+package docker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/moby/buildkit/session/secrets"
+	"github.com/pkg/errors"
+)
+
+func TestSecretStore(t *testing.T) {
+	store := &secretStore{
+		secrets: map[string][]byte{
+			"npmrc": []byte("//registry.npmjs.org/:_authToken=abc"),
+		},
+	}
+
+	data, err := store.GetSecret(context.Background(), "npmrc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != "//registry.npmjs.org/:_authToken=abc" {
+		t.Fatalf("Unexpected secret %s", string(data))
+	}
+
+	_, err = store.GetSecret(context.Background(), "token")
+	if errors.Cause(err) != secrets.ErrNotFound {
+		t.Fatalf("Expected secrets.ErrNotFound, got %v", err)
+	}
+}
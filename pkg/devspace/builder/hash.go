@@ -0,0 +1,112 @@
+package builder
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/docker/docker/api/types"
+)
+
+// GetBuildHash returns a hash over everything that influences the image build: the files in the
+// build context that are not ignored by a .dockerignore, the Dockerfile, the build options that change
+// the resulting image and the build engine, because the engines don't produce the same images
+func GetBuildHash(contextPath, dockerfilePath string, options *types.ImageBuildOptions, engine string) (string, error) {
+	hasher := sha256.New()
+
+	err := hashContext(hasher, contextPath)
+	if err != nil {
+		return "", err
+	}
+
+	// The Dockerfile is hashed separately, because it can be outside of the context or ignored
+	io.WriteString(hasher, "dockerfile\x00")
+	err = hashFile(hasher, dockerfilePath)
+	if err != nil {
+		return "", err
+	}
+
+	io.WriteString(hasher, "engine\x00"+engine+"\x00")
+
+	if options == nil {
+		options = &types.ImageBuildOptions{}
+	}
+
+	buildArgKeys := make([]string, 0, len(options.BuildArgs))
+	for key := range options.BuildArgs {
+		buildArgKeys = append(buildArgKeys, key)
+	}
+
+	sort.Strings(buildArgKeys)
+
+	for _, key := range buildArgKeys {
+		value := ""
+		if options.BuildArgs[key] != nil {
+			value = *options.BuildArgs[key]
+		}
+
+		io.WriteString(hasher, "arg\x00"+key+"\x00"+value+"\x00")
+	}
+
+	labelKeys := make([]string, 0, len(options.Labels))
+	for key := range options.Labels {
+		labelKeys = append(labelKeys, key)
+	}
+
+	sort.Strings(labelKeys)
+
+	for _, key := range labelKeys {
+		io.WriteString(hasher, "label\x00"+key+"\x00"+options.Labels[key]+"\x00")
+	}
+
+	// noCache, pull and cacheFrom only change how the image is built, so they are not part of the hash
+	io.WriteString(hasher, fmt.Sprintf("target\x00%s\x00network\x00%s\x00squash\x00%t\x00", options.Target, options.NetworkMode, options.Squash))
+
+	for _, extraHost := range options.ExtraHosts {
+		io.WriteString(hasher, "host\x00"+extraHost+"\x00")
+	}
+
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// hashContext hashes path, mode and content of all files within the context in a stable order
+func hashContext(hasher hash.Hash, contextPath string) error {
+	return WalkContext(contextPath, func(path, relativePath string, info os.FileInfo) error {
+		// Only the executable bit is relevant for the image, other permissions differ between checkouts
+		io.WriteString(hasher, fmt.Sprintf("%s\x00%t\x00%t\x00", relativePath, info.IsDir(), info.Mode()&0111 != 0))
+
+		if info.Mode()&os.ModeSymlink != 0 {
+			target, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+
+			io.WriteString(hasher, target+"\x00")
+		} else if info.Mode().IsRegular() {
+			return hashFile(hasher, path)
+		}
+
+		return nil
+	})
+}
+
+func hashFile(hasher hash.Hash, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	_, err = io.Copy(hasher, file)
+	if err != nil {
+		return err
+	}
+
+	io.WriteString(hasher, "\x00")
+	return nil
+}
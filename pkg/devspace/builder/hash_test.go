@@ -0,0 +1,117 @@
+package builder
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+)
+
+func TestGetBuildHash(t *testing.T) {
+	contextPath, err := ioutil.TempDir("", "devspace-hash")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(contextPath)
+
+	files := map[string]string{
+		"Dockerfile":              "FROM alpine",
+		"package.json":            "{}",
+		"src/index.js":            "console.log('hello')",
+		"node_modules/a/index.js": "module.exports = 1",
+		".dockerignore":           "node_modules",
+		".devspace/config.yaml":   "version: v1",
+	}
+
+	for path, content := range files {
+		writeFile(t, filepath.Join(contextPath, path), content)
+	}
+
+	dockerfilePath := filepath.Join(contextPath, "Dockerfile")
+	value := "1"
+	options := &types.ImageBuildOptions{BuildArgs: map[string]*string{"VERSION": &value}}
+	engine := "docker"
+
+	hash, err := GetBuildHash(contextPath, dockerfilePath, options, engine)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectHash := func(expectChanged bool, change string) {
+		newHash, err := GetBuildHash(contextPath, dockerfilePath, options, engine)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if (newHash != hash) != expectChanged {
+			t.Fatalf("Expected hash changed to be %t after %s", expectChanged, change)
+		}
+
+		hash = newHash
+	}
+
+	expectHash(false, "no change")
+
+	writeFile(t, filepath.Join(contextPath, "node_modules/a/index.js"), "module.exports = 2")
+	expectHash(false, "changing an ignored file")
+
+	writeFile(t, filepath.Join(contextPath, ".devspace/config.yaml"), "version: v2")
+	expectHash(false, "changing the devspace config")
+
+	writeFile(t, filepath.Join(contextPath, "src/index.js"), "console.log('world')")
+	expectHash(true, "changing a source file")
+
+	writeFile(t, filepath.Join(contextPath, "src/new.js"), "")
+	expectHash(true, "adding a file")
+
+	writeFile(t, dockerfilePath, "FROM alpine:3.8")
+	expectHash(true, "changing the Dockerfile")
+
+	value = "2"
+	expectHash(true, "changing a build arg")
+
+	options.Target = "production"
+	expectHash(true, "changing the target")
+
+	options.Labels = map[string]string{"team": "web"}
+	expectHash(true, "adding a label")
+
+	options.NetworkMode = "host"
+	expectHash(true, "changing the network")
+
+	options.ExtraHosts = []string{"db:10.0.0.1"}
+	expectHash(true, "adding an extra host")
+
+	options.Squash = true
+	expectHash(true, "squashing the layers")
+
+	options.NoCache = true
+	options.PullParent = true
+	options.CacheFrom = []string{"user/app:latest"}
+	expectHash(false, "changing noCache, pull and cacheFrom")
+
+	engine = "kaniko"
+	expectHash(true, "changing the engine")
+
+	err = os.Chmod(filepath.Join(contextPath, "src/index.js"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectHash(true, "making a file executable")
+}
+
+func writeFile(t *testing.T, path, content string) {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = ioutil.WriteFile(path, []byte(content), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
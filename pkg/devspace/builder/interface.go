@@ -5,6 +5,8 @@ import "github.com/docker/docker/api/types"
 // Interface defines methods for builders (e.g. docker, kaniko)
 type Interface interface {
 	Authenticate(username, password string, checkCredentialsStore bool) (*types.AuthConfig, error)
-	BuildImage(contextPath, dockerfilePath string, options *types.ImageBuildOptions) error
-	PushImage() error
+	BuildImage(contextPath, dockerfilePath string, options *types.ImageBuildOptions, secrets *BuildSecrets) error
+
+	// PushImage pushes the built image and returns the digest of the pushed manifest (e.g. sha256:...)
+	PushImage() (string, error)
 }
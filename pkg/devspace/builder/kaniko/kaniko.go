@@ -2,19 +2,20 @@ package kaniko
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/covexo/devspace/pkg/devspace/builder"
 	"github.com/covexo/devspace/pkg/devspace/builder/docker"
+	"github.com/covexo/devspace/pkg/devspace/config/v1"
 	"github.com/covexo/devspace/pkg/devspace/registry"
 
 	"github.com/covexo/devspace/pkg/devspace/clients/kubectl"
-	synctool "github.com/covexo/devspace/pkg/devspace/sync"
-	"github.com/covexo/devspace/pkg/util/ignoreutil"
 	"github.com/covexo/devspace/pkg/util/log"
 	"github.com/covexo/devspace/pkg/util/randutil"
 	"github.com/docker/docker/api/types"
-	k8sv1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/kubernetes/pkg/util/interrupt"
@@ -28,18 +29,34 @@ type Builder struct {
 	BuildNamespace string
 
 	allowInsecureRegistry bool
+	digest                string
+	config                *v1.KanikoBuildEngine
 	kubectl               *kubernetes.Clientset
+	log                   log.Logger
 }
 
+// executorImage is the kaniko debug image, which contains busybox to keep the build pod running.
+// Reading the build context from stdin requires at least v0.18.0
+const executorImage = "gcr.io/kaniko-project/executor:debug-v0.24.0"
+
+// digestFilePath is where the executor writes the digest of the pushed manifest to
+const digestFilePath = "/kaniko/digest"
+
 // NewBuilder creates a new kaniko.Builder instance
-func NewBuilder(registryURL, imageName, imageTag, buildNamespace string, kubectl *kubernetes.Clientset, allowInsecureRegistry bool) (*Builder, error) {
+func NewBuilder(registryURL, imageName, imageTag, buildNamespace string, config *v1.KanikoBuildEngine, kubectl *kubernetes.Clientset, allowInsecureRegistry bool, log log.Logger) (*Builder, error) {
+	if config == nil {
+		config = &v1.KanikoBuildEngine{}
+	}
+
 	return &Builder{
 		RegistryURL:           registryURL,
 		ImageName:             imageName,
 		ImageTag:              imageTag,
 		BuildNamespace:        buildNamespace,
 		allowInsecureRegistry: allowInsecureRegistry,
+		config:                config,
 		kubectl:               kubectl,
+		log:                   log,
 	}, nil
 }
 
@@ -48,7 +65,7 @@ func (b *Builder) Authenticate(username, password string, checkCredentialsStore
 	email := "noreply@devspace-cloud.com"
 
 	if len(username) == 0 {
-		dockerBuilder, dockerBuilderErr := docker.NewBuilder(b.RegistryURL, b.ImageName, b.ImageTag, false)
+		dockerBuilder, dockerBuilderErr := docker.NewBuilder(b.RegistryURL, b.ImageName, b.ImageTag, false, b.log)
 		if dockerBuilderErr != nil {
 			return nil, dockerBuilderErr
 		}
@@ -69,56 +86,29 @@ func (b *Builder) Authenticate(username, password string, checkCredentialsStore
 	return nil, registry.CreatePullSecret(b.kubectl, b.BuildNamespace, b.RegistryURL, username, password, email)
 }
 
-// BuildImage builds a dockerimage within a kaniko pod
-func (b *Builder) BuildImage(contextPath, dockerfilePath string, options *types.ImageBuildOptions) error {
-	pullSecretName := registry.GetRegistryAuthSecretName(b.RegistryURL)
+// BuildImage builds a dockerimage within a kaniko pod. The secrets are mounted into the build pod
+// at /run/secrets/<id> and deleted after the build
+func (b *Builder) BuildImage(contextPath, dockerfilePath string, options *types.ImageBuildOptions, secrets *builder.BuildSecrets) error {
+	if options == nil {
+		options = &types.ImageBuildOptions{}
+	}
+
+	err := validateOptions(options)
+	if err != nil {
+		return err
+	}
+	if secrets != nil && secrets.SSH != nil {
+		return fmt.Errorf("The kaniko build engine does not support ssh forwarding (use the docker engine instead)")
+	}
+
 	randString, _ := randutil.GenerateRandomString(12)
 	buildID := strings.ToLower(randString)
-	buildPod := &k8sv1.Pod{
-		ObjectMeta: metav1.ObjectMeta{
-			GenerateName: "devspace-build-",
-			Labels: map[string]string{
-				"devspace-build-id": buildID,
-			},
-		},
-		Spec: k8sv1.PodSpec{
-			Containers: []k8sv1.Container{
-				{
-					Name:            "kaniko",
-					Image:           "gcr.io/kaniko-project/executor:debug-60bdda4c49b699f5a21545cc8a050a5f3953223f",
-					ImagePullPolicy: k8sv1.PullIfNotPresent,
-					Command: []string{
-						"/busybox/sleep",
-					},
-					Args: []string{
-						"36000",
-					},
-					VolumeMounts: []k8sv1.VolumeMount{
-						{
-							Name:      pullSecretName,
-							MountPath: "/root/.docker",
-						},
-					},
-				},
-			},
-			Volumes: []k8sv1.Volume{
-				{
-					Name: pullSecretName,
-					VolumeSource: k8sv1.VolumeSource{
-						Secret: &k8sv1.SecretVolumeSource{
-							SecretName: pullSecretName,
-							Items: []k8sv1.KeyToPath{
-								{
-									Key:  k8sv1.DockerConfigJsonKey,
-									Path: "config.json",
-								},
-							},
-						},
-					},
-				},
-			},
-			RestartPolicy: k8sv1.RestartPolicyOnFailure,
-		},
+
+	buildSecret := getBuildSecret(buildID, secrets)
+
+	buildPod, err := b.getBuildPod(buildID, buildSecret)
+	if err != nil {
+		return err
 	}
 
 	deleteBuildPod := func() {
@@ -129,29 +119,44 @@ func (b *Builder) BuildImage(contextPath, dockerfilePath string, options *types.
 		})
 
 		if deleteErr != nil {
-			log.Errorf("Failed to delete build pod: %s", deleteErr.Error())
+			b.log.Errorf("Failed to delete build pod: %s", deleteErr.Error())
+		}
+
+		if buildSecret != nil {
+			deleteErr = b.kubectl.Core().Secrets(b.BuildNamespace).Delete(buildSecret.Name, &metav1.DeleteOptions{})
+			if deleteErr != nil && k8serrors.IsNotFound(deleteErr) == false {
+				b.log.Errorf("Failed to delete build secret %s: %v", buildSecret.Name, deleteErr)
+			}
 		}
 	}
 
 	intr := interrupt.New(nil, deleteBuildPod)
 
-	err := intr.Run(func() error {
+	err = intr.Run(func() error {
+		if buildSecret != nil {
+			_, err := b.kubectl.Core().Secrets(b.BuildNamespace).Create(buildSecret)
+			if err != nil {
+				return fmt.Errorf("Unable to create build secret: %v", err)
+			}
+		}
+
 		buildPodCreated, buildPodCreateErr := b.kubectl.Core().Pods(b.BuildNamespace).Create(buildPod)
 
 		if buildPodCreateErr != nil {
 			return fmt.Errorf("Unable to create build pod: %s", buildPodCreateErr.Error())
 		}
 
-		readyWaitTime := 2 * 60 * time.Second
+		readyWaitTime := b.getStartTimeout()
 		readyCheckInterval := 5 * time.Second
 		buildPodReady := false
 
-		log.StartWait("Waiting for kaniko build pod to start")
+		b.log.StartWait("Waiting for kaniko build pod to start")
 
 		for readyWaitTime > 0 {
-			buildPod, _ = b.kubectl.Core().Pods(b.BuildNamespace).Get(buildPodCreated.Name, metav1.GetOptions{})
-
-			if len(buildPod.Status.ContainerStatuses) > 0 && buildPod.Status.ContainerStatuses[0].Ready {
+			buildPod, err = b.kubectl.Core().Pods(b.BuildNamespace).Get(buildPodCreated.Name, metav1.GetOptions{})
+			if err != nil {
+				buildPod = buildPodCreated
+			} else if len(buildPod.Status.ContainerStatuses) > 0 && buildPod.Status.ContainerStatuses[0].Ready {
 				buildPodReady = true
 				break
 			}
@@ -160,72 +165,71 @@ func (b *Builder) BuildImage(contextPath, dockerfilePath string, options *types.
 			readyWaitTime = readyWaitTime - readyCheckInterval
 		}
 
-		log.StopWait()
-		log.Done("Kaniko build pod started")
+		b.log.StopWait()
 
 		if !buildPodReady {
-			return fmt.Errorf("Unable to start build pod")
+			return fmt.Errorf("Build pod %s didn't start within %s: %s", buildPod.Name, b.getStartTimeout().String(), getPodNotReadyReason(buildPod))
 		}
-		ignoreRules, ignoreRuleErr := ignoreutil.GetIgnoreRules(contextPath)
-
-		if ignoreRuleErr != nil {
-			return fmt.Errorf("Unable to parse .dockerignore files: %s", ignoreRuleErr.Error())
-		}
-
-		buildContainer := &buildPod.Spec.Containers[0]
 
-		log.StartWait("Uploading files to build container")
-		err := synctool.CopyToContainer(b.kubectl, buildPod, buildContainer, contextPath, "/src", ignoreRules)
+		b.log.Done("Kaniko build pod started")
 
+		contextDockerfilePath, err := builder.GetContextDockerfilePath(contextPath, dockerfilePath)
 		if err != nil {
-			return fmt.Errorf("Error uploading files to container: %s", err.Error())
+			return err
 		}
-		err = synctool.CopyToContainer(b.kubectl, buildPod, buildContainer, dockerfilePath, "/src", ignoreRules)
 
-		if err != nil {
-			return fmt.Errorf("Error uploading files to container: %s", err.Error())
-		}
-		log.StopWait()
-		log.Done("Uploaded files to container")
-
-		log.StartWait("Building container image")
+		b.log.StartWait("Building container image")
 
 		imageDestination := b.ImageName + ":" + b.ImageTag
 
 		if b.RegistryURL != "" {
 			imageDestination = strings.TrimSuffix(b.RegistryURL, "/") + "/" + imageDestination
 		}
-		containerBuildPath := "/src"
 		exitChannel := make(chan error)
-		kanikoBuildCmd := []string{
-			"/kaniko/executor",
-			"--dockerfile=" + containerBuildPath + "/Dockerfile",
-			"--context=dir://" + containerBuildPath,
-			"--destination=" + imageDestination,
-			"--single-snapshot",
-		}
-
-		if b.allowInsecureRegistry {
-			kanikoBuildCmd = append(kanikoBuildCmd, "--insecure-skip-tls-verify")
-		}
+		kanikoBuildCmd := append([]string{"/kaniko/executor"}, b.getExecutorArgs(imageDestination, contextDockerfilePath, options)...)
+		buildContainer := &buildPod.Spec.Containers[0]
 
 		stdin, stdout, stderr, execErr := kubectl.Exec(b.kubectl, buildPod, buildContainer.Name, kanikoBuildCmd, false, exitChannel)
-		stdin.Close()
-
 		if execErr != nil {
 			return fmt.Errorf("Failed to start image building: %s", execErr.Error())
 		}
 
-		lastKanikoOutput := formatKanikoOutput(stdout, stderr)
+		// The context is streamed while the output is read, kaniko starts the build after stdin is closed
+		uploadErrChan := make(chan error, 1)
+
+		go func() {
+			uploadErr := builder.WriteContextArchive(stdin, contextPath, dockerfilePath)
+			stdin.Close()
+
+			uploadErrChan <- uploadErr
+		}()
+
+		lastKanikoOutput := formatKanikoOutput(stdout, stderr, b.log)
 		exitError := <-exitChannel
 
-		log.StopWait()
+		// If the executor failed before it read the whole context, nothing reads stdin anymore and the
+		// upload would block forever. Closing stdin stops it, the upload error only matters if the build succeeded
+		stdin.Close()
+
+		b.log.StopWait()
 
 		if exitError != nil {
 			return fmt.Errorf("Error: %s, Last Kaniko Output: %s", exitError.Error(), lastKanikoOutput)
 		}
 
-		log.Done("Done building image")
+		uploadErr := <-uploadErrChan
+		if uploadErr != nil {
+			return fmt.Errorf("Error uploading build context: %v", uploadErr)
+		}
+
+		// The build pod is deleted afterwards, so the digest has to be read now
+		digest, _, err := kubectl.ExecBuffered(b.kubectl, buildPod, buildContainer.Name, []string{"cat", digestFilePath})
+		if err != nil {
+			return fmt.Errorf("Unable to read image digest: %v", err)
+		}
+
+		b.digest = strings.TrimSpace(string(digest))
+		b.log.Done("Done building image")
 
 		return nil
 	})
@@ -237,7 +241,89 @@ func (b *Builder) BuildImage(contextPath, dockerfilePath string, options *types.
 	return nil
 }
 
-// PushImage is required to implement builder.Interface
-func (b *Builder) PushImage() error {
+// getExecutorArgs returns the arguments for the kaniko executor
+func (b *Builder) getExecutorArgs(imageDestination, contextDockerfilePath string, options *types.ImageBuildOptions) []string {
+	args := []string{
+		"--dockerfile=" + contextDockerfilePath,
+		"--context=tar://stdin",
+		"--destination=" + imageDestination,
+		"--digest-file=" + digestFilePath,
+	}
+
+	// Build args are sorted, so that the arguments are stable
+	buildArgKeys := make([]string, 0, len(options.BuildArgs))
+	for key := range options.BuildArgs {
+		buildArgKeys = append(buildArgKeys, key)
+	}
+
+	sort.Strings(buildArgKeys)
+
+	for _, key := range buildArgKeys {
+		if options.BuildArgs[key] != nil {
+			args = append(args, "--build-arg="+key+"="+*options.BuildArgs[key])
+		}
+	}
+
+	// Labels are sorted like the build args
+	labelKeys := make([]string, 0, len(options.Labels))
+	for key := range options.Labels {
+		labelKeys = append(labelKeys, key)
+	}
+
+	sort.Strings(labelKeys)
+
+	for _, key := range labelKeys {
+		args = append(args, "--label="+key+"="+options.Labels[key])
+	}
+
+	if options.Target != "" {
+		args = append(args, "--target="+options.Target)
+	}
+
+	// The layer cache needs a snapshot per layer
+	if b.config.Cache != nil && *b.config.Cache {
+		args = append(args, "--cache=true")
+
+		if b.config.CacheRepo != nil && *b.config.CacheRepo != "" {
+			args = append(args, "--cache-repo="+*b.config.CacheRepo)
+		}
+	} else {
+		args = append(args, "--single-snapshot")
+	}
+
+	if b.allowInsecureRegistry {
+		args = append(args, "--insecure", "--skip-tls-verify")
+	}
+
+	return args
+}
+
+// validateOptions returns an error for build options the kaniko executor can't honour. Kaniko never
+// uses a local cache and always pulls the base images, so noCache and pull need no special handling
+func validateOptions(options *types.ImageBuildOptions) error {
+	unsupported := []string{}
+
+	if options.NetworkMode != "" {
+		unsupported = append(unsupported, "network")
+	}
+	if len(options.CacheFrom) > 0 {
+		unsupported = append(unsupported, "cacheFrom")
+	}
+	if len(options.ExtraHosts) > 0 {
+		unsupported = append(unsupported, "extraHosts")
+	}
+	if options.Squash {
+		unsupported = append(unsupported, "squash")
+	}
+
+	if len(unsupported) > 0 {
+		return fmt.Errorf("The kaniko build engine does not support the build options: %s (use the docker engine instead)", strings.Join(unsupported, ", "))
+	}
+
 	return nil
 }
+
+// PushImage only returns the digest of the manifest, because the executor already pushes the image
+func (b *Builder) PushImage() (string, error) {
+	return b.digest, nil
+}
@@ -0,0 +1,46 @@
+package kaniko
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/covexo/devspace/pkg/devspace/config/v1"
+	"github.com/docker/docker/api/types"
+)
+
+func TestGetExecutorArgs(t *testing.T) {
+	buildArg := "1.0"
+
+	builder := &Builder{config: &v1.KanikoBuildEngine{}}
+	args := builder.getExecutorArgs("registry.example.com/user/app:abc", "Dockerfile", &types.ImageBuildOptions{
+		BuildArgs: map[string]*string{"VERSION": &buildArg, "UNSET": nil},
+		Labels:    map[string]string{"b": "2", "a": "1"},
+		Target:    "dev",
+	})
+
+	expectedArgs := []string{
+		"--dockerfile=Dockerfile",
+		"--context=tar://stdin",
+		"--destination=registry.example.com/user/app:abc",
+		"--digest-file=" + digestFilePath,
+		"--build-arg=VERSION=1.0",
+		"--label=a=1",
+		"--label=b=2",
+		"--target=dev",
+		"--single-snapshot",
+	}
+
+	if reflect.DeepEqual(args, expectedArgs) == false {
+		t.Fatalf("Expected %v, got %v", expectedArgs, args)
+	}
+
+	err := validateOptions(&types.ImageBuildOptions{Labels: map[string]string{"a": "1"}})
+	if err != nil {
+		t.Fatalf("Expected labels to be supported: %v", err)
+	}
+
+	err = validateOptions(&types.ImageBuildOptions{Squash: true})
+	if err == nil {
+		t.Fatal("Expected an error for squash")
+	}
+}
@@ -0,0 +1,248 @@
+package kaniko
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/covexo/devspace/pkg/devspace/builder"
+	"github.com/covexo/devspace/pkg/devspace/config/v1"
+	"github.com/covexo/devspace/pkg/devspace/registry"
+	k8sv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// defaultStartTimeout is the time the build pod has to become ready if no startTimeout is configured
+const defaultStartTimeout = 2 * 60 * time.Second
+
+// secretsPath is where the build secrets are mounted. Kaniko doesn't snapshot mounted volumes,
+// so the secrets don't end up in the image
+const secretsPath = "/run/secrets"
+
+// getBuildSecret returns the temporary secret holding the build secrets or nil if there are none
+func getBuildSecret(buildID string, secrets *builder.BuildSecrets) *k8sv1.Secret {
+	if secrets == nil || len(secrets.Secrets) == 0 {
+		return nil
+	}
+
+	return &k8sv1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "devspace-build-secrets-" + buildID,
+			Labels: map[string]string{
+				"devspace-build-id": buildID,
+			},
+		},
+		Data: secrets.Secrets,
+	}
+}
+
+// getBuildPod returns the build pod with the options configured for the kaniko engine. The build secret
+// is only mounted into this pod
+func (b *Builder) getBuildPod(buildID string, buildSecret *k8sv1.Secret) (*k8sv1.Pod, error) {
+	pullSecretName := registry.GetRegistryAuthSecretName(b.RegistryURL)
+
+	image := executorImage
+	if b.config.Image != nil && *b.config.Image != "" {
+		image = *b.config.Image
+	}
+
+	resources, err := getResourceRequirements(b.config.Resources)
+	if err != nil {
+		return nil, err
+	}
+
+	buildPod := &k8sv1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "devspace-build-",
+			Labels: map[string]string{
+				"devspace-build-id": buildID,
+			},
+			Annotations: toStringMap(b.config.Annotations),
+		},
+		Spec: k8sv1.PodSpec{
+			Containers: []k8sv1.Container{
+				{
+					Name:            "kaniko",
+					Image:           image,
+					ImagePullPolicy: k8sv1.PullIfNotPresent,
+					Command: []string{
+						"/busybox/sleep",
+					},
+					Args: []string{
+						"36000",
+					},
+					Resources: resources,
+					VolumeMounts: []k8sv1.VolumeMount{
+						{
+							Name:      pullSecretName,
+							MountPath: "/root/.docker",
+						},
+					},
+				},
+			},
+			NodeSelector: toStringMap(b.config.NodeSelector),
+			Tolerations:  getTolerations(b.config.Tolerations),
+			Volumes: []k8sv1.Volume{
+				{
+					Name: pullSecretName,
+					VolumeSource: k8sv1.VolumeSource{
+						Secret: &k8sv1.SecretVolumeSource{
+							SecretName: pullSecretName,
+							Items: []k8sv1.KeyToPath{
+								{
+									Key:  k8sv1.DockerConfigJsonKey,
+									Path: "config.json",
+								},
+							},
+						},
+					},
+				},
+			},
+			RestartPolicy: k8sv1.RestartPolicyOnFailure,
+		},
+	}
+
+	if b.config.ServiceAccount != nil && *b.config.ServiceAccount != "" {
+		buildPod.Spec.ServiceAccountName = *b.config.ServiceAccount
+	}
+
+	if buildSecret != nil {
+		buildPod.Spec.Volumes = append(buildPod.Spec.Volumes, k8sv1.Volume{
+			Name: "build-secrets",
+			VolumeSource: k8sv1.VolumeSource{
+				Secret: &k8sv1.SecretVolumeSource{
+					SecretName: buildSecret.Name,
+				},
+			},
+		})
+
+		buildPod.Spec.Containers[0].VolumeMounts = append(buildPod.Spec.Containers[0].VolumeMounts, k8sv1.VolumeMount{
+			Name:      "build-secrets",
+			MountPath: secretsPath,
+			ReadOnly:  true,
+		})
+	}
+
+	return buildPod, nil
+}
+
+// getStartTimeout returns the time the build pod has to become ready
+func (b *Builder) getStartTimeout() time.Duration {
+	if b.config.StartTimeout != nil && *b.config.StartTimeout > 0 {
+		return time.Duration(*b.config.StartTimeout) * time.Second
+	}
+
+	return defaultStartTimeout
+}
+
+// getPodNotReadyReason explains why the build pod is not ready, e.g. because it can't be scheduled
+func getPodNotReadyReason(pod *k8sv1.Pod) string {
+	if pod == nil {
+		return "build pod not found"
+	}
+
+	for _, containerStatus := range pod.Status.ContainerStatuses {
+		if containerStatus.State.Waiting != nil && containerStatus.State.Waiting.Reason != "" {
+			return containerStatus.State.Waiting.Reason + " " + containerStatus.State.Waiting.Message
+		}
+	}
+
+	for _, condition := range pod.Status.Conditions {
+		if condition.Status == k8sv1.ConditionFalse && condition.Message != "" {
+			return condition.Message
+		}
+	}
+
+	return "pod is " + string(pod.Status.Phase)
+}
+
+func getResourceRequirements(config *v1.ResourceRequirements) (k8sv1.ResourceRequirements, error) {
+	resources := k8sv1.ResourceRequirements{}
+	if config == nil {
+		return resources, nil
+	}
+
+	var err error
+
+	resources.Requests, err = getResourceList(config.Requests)
+	if err != nil {
+		return resources, fmt.Errorf("Invalid resource requests: %v", err)
+	}
+
+	resources.Limits, err = getResourceList(config.Limits)
+	if err != nil {
+		return resources, fmt.Errorf("Invalid resource limits: %v", err)
+	}
+
+	return resources, nil
+}
+
+func getResourceList(config *map[string]*string) (k8sv1.ResourceList, error) {
+	if config == nil {
+		return nil, nil
+	}
+
+	resourceList := k8sv1.ResourceList{}
+
+	for name, value := range *config {
+		if value == nil {
+			continue
+		}
+
+		quantity, err := resource.ParseQuantity(*value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s (%v)", name, *value, err)
+		}
+
+		resourceList[k8sv1.ResourceName(name)] = quantity
+	}
+
+	return resourceList, nil
+}
+
+func getTolerations(config *[]*v1.Toleration) []k8sv1.Toleration {
+	if config == nil {
+		return nil
+	}
+
+	tolerations := make([]k8sv1.Toleration, 0, len(*config))
+
+	for _, tolerationConfig := range *config {
+		toleration := k8sv1.Toleration{
+			TolerationSeconds: tolerationConfig.TolerationSeconds,
+		}
+
+		if tolerationConfig.Key != nil {
+			toleration.Key = *tolerationConfig.Key
+		}
+		if tolerationConfig.Operator != nil {
+			toleration.Operator = k8sv1.TolerationOperator(*tolerationConfig.Operator)
+		}
+		if tolerationConfig.Value != nil {
+			toleration.Value = *tolerationConfig.Value
+		}
+		if tolerationConfig.Effect != nil {
+			toleration.Effect = k8sv1.TaintEffect(*tolerationConfig.Effect)
+		}
+
+		tolerations = append(tolerations, toleration)
+	}
+
+	return tolerations
+}
+
+func toStringMap(config *map[string]*string) map[string]string {
+	if config == nil {
+		return nil
+	}
+
+	stringMap := map[string]string{}
+
+	for key, value := range *config {
+		if value != nil {
+			stringMap[key] = *value
+		}
+	}
+
+	return stringMap
+}
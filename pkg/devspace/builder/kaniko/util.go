@@ -15,7 +15,7 @@ type OutputFormat struct {
 	Replacement string
 }
 
-func formatKanikoOutput(stdout io.ReadCloser, stderr io.ReadCloser) string {
+func formatKanikoOutput(stdout io.ReadCloser, stderr io.ReadCloser, log log.Logger) string {
 	wg := &sync.WaitGroup{}
 	lastLine := ""
 	outputFormats := []OutputFormat{
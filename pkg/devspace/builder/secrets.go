@@ -0,0 +1,35 @@
+package builder
+
+import "sort"
+
+// BuildSecrets holds the secrets and the ssh agent a build can access. They are passed to the builders
+// separately from the build options, so that they never end up in the image history or the build hash
+type BuildSecrets struct {
+	// Secrets maps the secret id to its content
+	Secrets map[string][]byte
+
+	// SSH holds the agent socket or the private keys that are forwarded with the ssh id "default".
+	// An empty slice forwards the agent of $SSH_AUTH_SOCK, nil disables ssh forwarding
+	SSH []string
+}
+
+// IsEmpty returns true if neither secrets nor ssh forwarding are configured
+func (s *BuildSecrets) IsEmpty() bool {
+	return s == nil || (len(s.Secrets) == 0 && s.SSH == nil)
+}
+
+// SecretIDs returns the sorted ids of the secrets
+func (s *BuildSecrets) SecretIDs() []string {
+	if s == nil {
+		return nil
+	}
+
+	ids := make([]string, 0, len(s.Secrets))
+	for id := range s.Secrets {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+
+	return ids
+}
@@ -0,0 +1,195 @@
+package builder
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/covexo/devspace/pkg/util/randutil"
+	"gopkg.in/src-d/go-git.v4"
+)
+
+// Tag strategies that can be configured with images[*].tagStrategy. Any other value
+// is used as Go template, e.g. "{{.GitCommit}}-{{.Timestamp}}"
+const (
+	TagStrategyRandom      = "random"
+	TagStrategyGitSha      = "git-sha"
+	TagStrategyTimestamp   = "timestamp"
+	TagStrategyContentHash = "content-hash"
+)
+
+// DefaultTagStrategy is used if no tag strategy is configured
+const DefaultTagStrategy = TagStrategyRandom
+
+// tagRegex matches valid docker image tags
+var tagRegex = regexp.MustCompile(`^[a-zA-Z0-9_][a-zA-Z0-9_.-]{0,127}$`)
+
+// TagValues provides the values for an image tag. Its methods can be used in tag templates
+type TagValues struct {
+	contextPath string
+	buildHash   string
+	now         time.Time
+}
+
+// GetImageTag returns a new image tag according to the tag strategy. The build hash is the
+// hash returned by GetBuildHash and the context path is used to find the git repository
+func GetImageTag(tagStrategy, contextPath, buildHash string) (string, error) {
+	values := &TagValues{
+		contextPath: contextPath,
+		buildHash:   buildHash,
+		now:         time.Now(),
+	}
+
+	var tag string
+	var err error
+
+	switch tagStrategy {
+	case "", TagStrategyRandom:
+		tag, err = values.Random()
+	case TagStrategyGitSha:
+		tag, err = values.GitSha()
+	case TagStrategyTimestamp:
+		tag = values.Timestamp()
+	case TagStrategyContentHash:
+		tag = values.ContentHash()
+	default:
+		// A strategy without template action is most likely a typo and would push every build to the same tag
+		if strings.Contains(tagStrategy, "{{") == false {
+			return "", fmt.Errorf("Unknown tag strategy '%s'", tagStrategy)
+		}
+
+		tag, err = values.execute(tagStrategy)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	if tagRegex.MatchString(tag) == false {
+		return "", fmt.Errorf("Tag strategy '%s' created the invalid image tag '%s'", tagStrategy, tag)
+	}
+
+	return tag, nil
+}
+
+func (t *TagValues) execute(tagTemplate string) (string, error) {
+	parsedTemplate, err := template.New("tag").Option("missingkey=error").Parse(tagTemplate)
+	if err != nil {
+		return "", fmt.Errorf("Unknown tag strategy or invalid template '%s': %v", tagTemplate, err)
+	}
+
+	buffer := &bytes.Buffer{}
+
+	err = parsedTemplate.Execute(buffer, t)
+	if err != nil {
+		return "", fmt.Errorf("Error executing tag template '%s': %v", tagTemplate, err)
+	}
+
+	return strings.TrimSpace(buffer.String()), nil
+}
+
+// Random returns a random string with 7 characters
+func (t *TagValues) Random() (string, error) {
+	return randutil.GenerateRandomString(7)
+}
+
+// Timestamp returns the current UTC time in the format 20060102150405
+func (t *TagValues) Timestamp() string {
+	return t.now.UTC().Format("20060102150405")
+}
+
+// ContentHash returns the first 12 characters of the build hash
+func (t *TagValues) ContentHash() string {
+	if len(t.buildHash) > 12 {
+		return t.buildHash[:12]
+	}
+
+	return t.buildHash
+}
+
+// GitCommit returns the short sha of the commit checked out in the repository containing the build context
+func (t *TagValues) GitCommit() (string, error) {
+	repo, err := t.openRepository()
+	if err != nil {
+		return "", err
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return "", fmt.Errorf("Unable to get git HEAD: %v", err)
+	}
+
+	return head.Hash().String()[:7], nil
+}
+
+// GitSha returns the short commit sha with the suffix -dirty if the worktree has uncommitted changes
+func (t *TagValues) GitSha() (string, error) {
+	commit, err := t.GitCommit()
+	if err != nil {
+		return "", err
+	}
+
+	dirty, err := t.GitDirty()
+	if err != nil {
+		return "", err
+	}
+
+	return commit + dirty, nil
+}
+
+// GitDirty returns "-dirty" if the worktree has uncommitted changes and an empty string otherwise. Files
+// in .devspace folders are ignored, because they are rewritten by every build
+func (t *TagValues) GitDirty() (string, error) {
+	repo, err := t.openRepository()
+	if err != nil {
+		return "", err
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return "", fmt.Errorf("Unable to open git worktree: %v", err)
+	}
+
+	status, err := worktree.Status()
+	if err != nil {
+		return "", fmt.Errorf("Unable to get git status: %v", err)
+	}
+
+	for path, fileStatus := range status {
+		if isDevSpaceFile(path) {
+			continue
+		}
+
+		if fileStatus.Worktree != git.Unmodified || fileStatus.Staging != git.Unmodified {
+			return "-dirty", nil
+		}
+	}
+
+	return "", nil
+}
+
+// isDevSpaceFile checks if the path (relative to the repository) is inside a .devspace folder
+func isDevSpaceFile(path string) bool {
+	for _, part := range strings.Split(filepath.ToSlash(path), "/") {
+		if part == ".devspace" {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (t *TagValues) openRepository() (*git.Repository, error) {
+	repo, err := git.PlainOpenWithOptions(t.contextPath, &git.PlainOpenOptions{
+		DetectDotGit: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Unable to open git repository for %s: %v", t.contextPath, err)
+	}
+
+	return repo, nil
+}
@@ -0,0 +1,118 @@
+package builder
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+func TestGetImageTag(t *testing.T) {
+	buildHash := "3f2a8c1e9b7d4c6a5e0f1b2c3d4e5f6a7b8c9d0e1f2a3b4c5d6e7f8091a2b3c4"
+
+	testCases := []struct {
+		tagStrategy string
+		expectedTag *regexp.Regexp
+	}{
+		{"", regexp.MustCompile(`^[a-zA-Z0-9]{7}$`)},
+		{TagStrategyRandom, regexp.MustCompile(`^[a-zA-Z0-9]{7}$`)},
+		{TagStrategyTimestamp, regexp.MustCompile(`^[0-9]{14}$`)},
+		{TagStrategyContentHash, regexp.MustCompile(`^3f2a8c1e9b7d$`)},
+		{"v1-{{.ContentHash}}-{{.Timestamp}}", regexp.MustCompile(`^v1-3f2a8c1e9b7d-[0-9]{14}$`)},
+	}
+
+	for _, testCase := range testCases {
+		tag, err := GetImageTag(testCase.tagStrategy, "", buildHash)
+		if err != nil {
+			t.Fatalf("Tag strategy '%s': %v", testCase.tagStrategy, err)
+		}
+
+		if testCase.expectedTag.MatchString(tag) == false {
+			t.Fatalf("Tag strategy '%s': unexpected tag %s", testCase.tagStrategy, tag)
+		}
+	}
+
+	for _, tagStrategy := range []string{"unknown strategy", "gitsha", "{{.Unknown}}", "{{.ContentHash}}:latest"} {
+		_, err := GetImageTag(tagStrategy, "", buildHash)
+		if err == nil {
+			t.Fatalf("Expected an error for tag strategy '%s'", tagStrategy)
+		}
+	}
+}
+
+func TestGetImageTagGitSha(t *testing.T) {
+	repoPath, err := ioutil.TempDir("", "devspace-tag")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(repoPath)
+
+	repo, err := git.PlainInit(repoPath, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeFile(t, filepath.Join(repoPath, "app", "Dockerfile"), "FROM alpine")
+	writeFile(t, filepath.Join(repoPath, ".devspace", "config.yaml"), "version: v1")
+
+	for _, path := range []string{"app/Dockerfile", ".devspace/config.yaml"} {
+		_, err = worktree.Add(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	commit, err := worktree.Commit("Initial commit", &git.CommitOptions{
+		Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The repository is found from a context in a sub directory
+	contextPath := filepath.Join(repoPath, "app")
+
+	tag, err := GetImageTag(TagStrategyGitSha, contextPath, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tag != commit.String()[:7] {
+		t.Fatalf("Expected tag %s, got %s", commit.String()[:7], tag)
+	}
+
+	// Changes of the DevSpace files don't make the worktree dirty
+	writeFile(t, filepath.Join(repoPath, ".devspace", "config.yaml"), "version: v1\nimages: {}")
+	writeFile(t, filepath.Join(repoPath, ".devspace", "generated.yaml"), "tag: abc")
+
+	tag, err = GetImageTag(TagStrategyGitSha, contextPath, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tag != commit.String()[:7] {
+		t.Fatalf("Expected tag %s after changing .devspace files, got %s", commit.String()[:7], tag)
+	}
+
+	writeFile(t, filepath.Join(contextPath, "Dockerfile"), "FROM node")
+
+	tag, err = GetImageTag(TagStrategyGitSha, contextPath, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tag != commit.String()[:7]+"-dirty" {
+		t.Fatalf("Expected tag %s-dirty, got %s", commit.String()[:7], tag)
+	}
+}
@@ -45,21 +45,31 @@ func NewClient() (*kubernetes.Clientset, error) {
 // IsMinikube returns true if the Kubernetes cluster is a minikube
 func IsMinikube() bool {
 	if isMinikubeVar == nil {
-		loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
-		kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &clientcmd.ConfigOverrides{})
-		cfg, err := kubeConfig.RawConfig()
-
+		currentContext, err := GetCurrentContext()
 		if err != nil {
 			return false
 		}
 
-		isMinikube := cfg.CurrentContext == "minikube"
+		isMinikube := currentContext == "minikube"
 		isMinikubeVar = &isMinikube
 	}
 
 	return *isMinikubeVar
 }
 
+// GetCurrentContext returns the name of the current context of the kube config
+func GetCurrentContext() (string, error) {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &clientcmd.ConfigOverrides{})
+	cfg, err := kubeConfig.RawConfig()
+
+	if err != nil {
+		return "", err
+	}
+
+	return cfg.CurrentContext, nil
+}
+
 // GetFirstRunningPod retrieves the first pod that is found that has the status "Running" using the label selector string
 func GetFirstRunningPod(kubectl *kubernetes.Clientset, labelSelector, namespace string) (*k8sv1.Pod, error) {
 	podList, err := kubectl.Core().Pods(namespace).List(metav1.ListOptions{
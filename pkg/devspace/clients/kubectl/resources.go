@@ -0,0 +1,245 @@
+package kubectl
+
+import (
+	"errors"
+	"fmt"
+
+	k8sv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+)
+
+// GetServicePod retrieves the service with the given name (or the first service matching the label selector)
+// and a ready pod that backs it
+func GetServicePod(kubectl *kubernetes.Clientset, name, labelSelector, namespace string) (*k8sv1.Service, *k8sv1.Pod, error) {
+	var service *k8sv1.Service
+
+	if name != "" {
+		var err error
+
+		service, err = kubectl.Core().Services(namespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			return nil, nil, err
+		}
+	} else {
+		serviceList, err := kubectl.Core().Services(namespace).List(metav1.ListOptions{
+			LabelSelector: labelSelector,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if len(serviceList.Items) == 0 {
+			return nil, nil, fmt.Errorf("No service found with selector %s", labelSelector)
+		}
+
+		service = &serviceList.Items[0]
+	}
+
+	if len(service.Spec.Selector) == 0 {
+		return nil, nil, fmt.Errorf("Service %s has no pod selector", service.Name)
+	}
+
+	podList, err := kubectl.Core().Pods(namespace).List(metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(labels.Set(service.Spec.Selector)).String(),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, pod := range podList.Items {
+		if IsPodReady(&pod) {
+			return service, &pod, nil
+		}
+	}
+
+	return nil, nil, fmt.Errorf("No ready pod found for service %s", service.Name)
+}
+
+// GetServiceTargetPort returns the container port the given service port is mapped to in the pod
+func GetServiceTargetPort(service *k8sv1.Service, pod *k8sv1.Pod, port int) (int, error) {
+	for _, servicePort := range service.Spec.Ports {
+		if int(servicePort.Port) != port {
+			continue
+		}
+
+		// Named ports are resolved with the container ports of the pod
+		if servicePort.TargetPort.Type == intstr.String {
+			for _, container := range pod.Spec.Containers {
+				for _, containerPort := range container.Ports {
+					if containerPort.Name == servicePort.TargetPort.StrVal {
+						return int(containerPort.ContainerPort), nil
+					}
+				}
+			}
+
+			return 0, fmt.Errorf("Pod %s has no port named %s", pod.Name, servicePort.TargetPort.StrVal)
+		}
+
+		if servicePort.TargetPort.IntVal == 0 {
+			return port, nil
+		}
+
+		return int(servicePort.TargetPort.IntVal), nil
+	}
+
+	return 0, fmt.Errorf("Service %s has no port %d", service.Name, port)
+}
+
+// GetDeploymentPod retrieves a ready pod that is owned by the deployment with the given name (or the first
+// deployment matching the label selector)
+func GetDeploymentPod(kubectl *kubernetes.Clientset, name, labelSelector, namespace string) (*k8sv1.Pod, error) {
+	var selector *metav1.LabelSelector
+	var uid types.UID
+
+	if name != "" {
+		deployment, err := kubectl.AppsV1().Deployments(namespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+
+		name, selector, uid = deployment.Name, deployment.Spec.Selector, deployment.UID
+	} else {
+		deploymentList, err := kubectl.AppsV1().Deployments(namespace).List(metav1.ListOptions{
+			LabelSelector: labelSelector,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if len(deploymentList.Items) == 0 {
+			return nil, fmt.Errorf("No deployment found with selector %s", labelSelector)
+		}
+
+		deployment := deploymentList.Items[0]
+		name, selector, uid = deployment.Name, deployment.Spec.Selector, deployment.UID
+	}
+
+	podSelector, err := getSelectorString(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	// Pods of a deployment are owned by its replica sets
+	replicaSetList, err := kubectl.AppsV1().ReplicaSets(namespace).List(metav1.ListOptions{
+		LabelSelector: podSelector,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	owners := make(map[types.UID]bool)
+
+	for _, replicaSet := range replicaSetList.Items {
+		controllerRef := metav1.GetControllerOf(&replicaSet)
+		if controllerRef != nil && controllerRef.UID == uid {
+			owners[replicaSet.UID] = true
+		}
+	}
+
+	pod, err := getReadyOwnedPod(kubectl, podSelector, namespace, owners)
+	if err != nil {
+		return nil, err
+	} else if pod == nil {
+		return nil, fmt.Errorf("No ready pod found for deployment %s", name)
+	}
+
+	return pod, nil
+}
+
+// GetStatefulSetPod retrieves a ready pod that is owned by the statefulset with the given name (or the first
+// statefulset matching the label selector)
+func GetStatefulSetPod(kubectl *kubernetes.Clientset, name, labelSelector, namespace string) (*k8sv1.Pod, error) {
+	var selector *metav1.LabelSelector
+	var uid types.UID
+
+	if name != "" {
+		statefulSet, err := kubectl.AppsV1().StatefulSets(namespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+
+		name, selector, uid = statefulSet.Name, statefulSet.Spec.Selector, statefulSet.UID
+	} else {
+		statefulSetList, err := kubectl.AppsV1().StatefulSets(namespace).List(metav1.ListOptions{
+			LabelSelector: labelSelector,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if len(statefulSetList.Items) == 0 {
+			return nil, fmt.Errorf("No statefulset found with selector %s", labelSelector)
+		}
+
+		statefulSet := statefulSetList.Items[0]
+		name, selector, uid = statefulSet.Name, statefulSet.Spec.Selector, statefulSet.UID
+	}
+
+	podSelector, err := getSelectorString(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	pod, err := getReadyOwnedPod(kubectl, podSelector, namespace, map[types.UID]bool{uid: true})
+	if err != nil {
+		return nil, err
+	} else if pod == nil {
+		return nil, fmt.Errorf("No ready pod found for statefulset %s", name)
+	}
+
+	return pod, nil
+}
+
+// IsPodReady returns true if the pod is running and all of its containers are ready
+func IsPodReady(pod *k8sv1.Pod) bool {
+	if pod.DeletionTimestamp != nil || pod.Status.Phase != k8sv1.PodRunning {
+		return false
+	}
+
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == k8sv1.PodReady {
+			return condition.Status == k8sv1.ConditionTrue
+		}
+	}
+
+	return false
+}
+
+func getSelectorString(selector *metav1.LabelSelector) (string, error) {
+	if selector == nil {
+		return "", errors.New("No selector defined")
+	}
+
+	labelSelector, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return "", err
+	}
+
+	return labelSelector.String(), nil
+}
+
+func getReadyOwnedPod(kubectl *kubernetes.Clientset, labelSelector, namespace string, owners map[types.UID]bool) (*k8sv1.Pod, error) {
+	podList, err := kubectl.Core().Pods(namespace).List(metav1.ListOptions{
+		LabelSelector: labelSelector,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pod := range podList.Items {
+		controllerRef := metav1.GetControllerOf(&pod)
+		if controllerRef == nil || owners[controllerRef.UID] == false {
+			continue
+		}
+
+		if IsPodReady(&pod) {
+			return &pod, nil
+		}
+	}
+
+	return nil, nil
+}
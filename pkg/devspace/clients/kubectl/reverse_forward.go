@@ -0,0 +1,324 @@
+package kubectl
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/covexo/devspace/pkg/util/log"
+	k8sv1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// reverseForwardListener is executed inside the container. socat listens on the remote port %[1]s and runs
+// connect.sh for every connection. The script announces the connection with a single byte and waits until
+// an accept session of the DevSpace CLI picks it up on the unix socket, so that any number of connections
+// can be open at the same time. A listener of a previous run that is still alive is stopped first. As soon
+// as socat listens on the port, the script prints reverseForwardReady
+var reverseForwardListener = `command -v socat >/dev/null 2>&1 || { echo "socat is not installed in the container, but is required for reverse port forwarding" >&2; exit 1; }
+dir=/tmp/devspace-reverse-%[1]s
+mkdir -p "$dir"
+pid=$(cat "$dir/pid" 2>/dev/null)
+[ -n "$pid" ] && [ "$(cat /proc/$pid/comm 2>/dev/null)" = socat ] && kill "$pid"
+cat > "$dir/connect.sh" <<'EOF'
+{ printf x; exec cat; } | exec socat - "UNIX-CONNECT:$1/socket,retry=100,interval=0.1"
+EOF
+socat -d -d "TCP-LISTEN:%[1]s,fork,reuseaddr" "SYSTEM:sh $dir/connect.sh $dir" 2>"$dir/listener.log" &
+pid=$!
+echo $pid > "$dir/pid"
+while kill -0 $pid 2>/dev/null; do
+  if grep -q "listening on" "$dir/listener.log"; then
+    echo ` + reverseForwardReady + `
+    wait $pid
+    exit $?
+  fi
+  sleep 0.1
+done
+cat "$dir/listener.log" >&2
+exit 1`
+
+// reverseForwardReady is printed by the listener as soon as it accepts connections
+const reverseForwardReady = "devspace-reverse-forward-ready"
+
+// reverseReadyTimeout is the maximum time to wait until the listener is ready
+const reverseReadyTimeout = 30 * time.Second
+
+// reverseForwardAccept is executed inside the container. It accepts a single connection of the listener on
+// port %[1]s and connects it with stdin and stdout of the exec session
+var reverseForwardAccept = `exec socat "UNIX-LISTEN:/tmp/devspace-reverse-%[1]s/socket,unlink-early,unlink-close=0" -`
+
+// reverseSessionRetryInterval is the time to wait before a failed session is started again, e.g. while the
+// pod is restarting
+const reverseSessionRetryInterval = 2 * time.Second
+
+// ReverseForwardPorts makes the local ports available inside the container of the pod (ports are specified
+// as local:remote). A listener in the container accepts the remote connections and every connection is
+// tunnelled through its own exec session
+func ReverseForwardPorts(kubectlClient *kubernetes.Clientset, pod *k8sv1.Pod, container string, ports []string, stopChan chan struct{}, readyChan chan struct{}) error {
+	logFile := log.GetFileLogger("portforwarding")
+	waitGroup := &sync.WaitGroup{}
+
+	for _, port := range ports {
+		mapping := strings.Split(port, ":")
+		if len(mapping) != 2 {
+			return fmt.Errorf("Invalid port mapping %s", port)
+		}
+
+		localPort, remotePort := mapping[0], mapping[1]
+		listenerCommand := []string{"sh", "-c", fmt.Sprintf(reverseForwardListener, remotePort)}
+		acceptCommand := []string{"sh", "-c", fmt.Sprintf(reverseForwardAccept, remotePort)}
+
+		// Start the listener synchronously, so that errors like a missing socat are reported
+		listener, err := startReverseListener(kubectlClient, pod, container, listenerCommand)
+		if err != nil {
+			return fmt.Errorf("Unable to listen on port %s in pod %s: %v", remotePort, pod.Name, err)
+		}
+
+		waitGroup.Add(2)
+
+		// The listener is started again if it exits, e.g. because the container was restarted
+		go func(port string, listener *reverseSession) {
+			defer waitGroup.Done()
+
+			for {
+				if listener != nil {
+					select {
+					case <-stopChan:
+						listener.close()
+						return
+					case err := <-listener.done:
+						listener.close()
+						logFile.Errorf("Reverse port forwarding %s: listener exited: %v %s", port, err, strings.TrimSpace(listener.stderr.String()))
+					}
+				}
+
+				select {
+				case <-stopChan:
+					return
+				case <-time.After(reverseSessionRetryInterval):
+				}
+
+				var err error
+
+				listener, err = startReverseListener(kubectlClient, pod, container, listenerCommand)
+				if err != nil {
+					logFile.Errorf("Reverse port forwarding %s: %v", port, err)
+				}
+			}
+		}(port, listener)
+
+		// There is always one accept session waiting for the next connection
+		go func(port, localPort string) {
+			defer waitGroup.Done()
+
+			for {
+				session, err := startReverseSession(kubectlClient, pod, container, acceptCommand)
+				if err != nil {
+					logFile.Errorf("Reverse port forwarding %s: %v", port, err)
+
+					select {
+					case <-stopChan:
+						return
+					case <-time.After(reverseSessionRetryInterval):
+					}
+
+					continue
+				}
+
+				if session.waitForConnection(stopChan) == false {
+					session.close()
+
+					select {
+					case <-stopChan:
+						return
+					default:
+					}
+
+					// The session ended without a connection, e.g. because the container was restarted
+					session.waitForStderr()
+					logFile.Errorf("Reverse port forwarding %s: accept session exited: %s", port, strings.TrimSpace(session.stderr.String()))
+
+					select {
+					case <-stopChan:
+						return
+					case <-time.After(reverseSessionRetryInterval):
+					}
+
+					continue
+				}
+
+				go func() {
+					err := session.tunnel(localPort, stopChan)
+					if err != nil {
+						logFile.Errorf("Reverse port forwarding %s: %v", port, err)
+					}
+				}()
+			}
+		}(port, localPort)
+	}
+
+	if readyChan != nil {
+		close(readyChan)
+	}
+
+	waitGroup.Wait()
+	return nil
+}
+
+type reverseSession struct {
+	stdin      io.WriteCloser
+	stdout     io.ReadCloser
+	stderr     *bytes.Buffer
+	stderrDone chan struct{}
+	done       chan error
+}
+
+func startReverseSession(kubectlClient *kubernetes.Clientset, pod *k8sv1.Pod, container string, command []string) (*reverseSession, error) {
+	done := make(chan error, 1)
+
+	stdin, stdout, stderr, err := Exec(kubectlClient, pod, container, command, false, done)
+	if err != nil {
+		return nil, err
+	}
+
+	session := &reverseSession{
+		stdin:      stdin,
+		stdout:     stdout,
+		stderr:     &bytes.Buffer{},
+		stderrDone: make(chan struct{}),
+		done:       done,
+	}
+
+	go func() {
+		io.Copy(session.stderr, stderr)
+		close(session.stderrDone)
+	}()
+
+	return session, nil
+}
+
+// startReverseListener starts the listener and waits until it reports that it accepts connections
+func startReverseListener(kubectlClient *kubernetes.Clientset, pod *k8sv1.Pod, container string, command []string) (*reverseSession, error) {
+	session, err := startReverseSession(kubectlClient, pod, container, command)
+	if err != nil {
+		return nil, err
+	}
+
+	err = session.waitForReady(reverseReadyTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// waitForReady waits until the helper prints the ready line. If the helper exits before, the session
+// is closed and the error output of the helper is returned
+func (r *reverseSession) waitForReady(timeout time.Duration) error {
+	ready := make(chan bool, 1)
+
+	go func() {
+		line, err := readLine(r.stdout)
+		ready <- err == nil && line == reverseForwardReady
+	}()
+
+	select {
+	case ok := <-ready:
+		if ok {
+			return nil
+		}
+	case <-r.done:
+	case <-time.After(timeout):
+		r.close()
+		return fmt.Errorf("Helper not ready after %v", timeout)
+	}
+
+	r.close()
+	r.waitForStderr()
+
+	return fmt.Errorf("Helper exited: %s", strings.TrimSpace(r.stderr.String()))
+}
+
+// waitForStderr waits shortly until the error output of the ended session is read completely
+func (r *reverseSession) waitForStderr() {
+	select {
+	case <-r.stderrDone:
+	case <-time.After(time.Second):
+	}
+}
+
+// readLine reads a single line without reading ahead, so that the reader can still be used afterwards
+func readLine(reader io.Reader) (string, error) {
+	line := []byte{}
+	buffer := make([]byte, 1)
+
+	for {
+		_, err := io.ReadFull(reader, buffer)
+		if err != nil {
+			return "", err
+		}
+		if buffer[0] == '\n' {
+			return string(line), nil
+		}
+
+		line = append(line, buffer[0])
+	}
+}
+
+// waitForConnection waits until the listener hands a connection to the session, which is announced
+// with a single byte. It returns false if the session ended or the forwarding was stopped before
+func (r *reverseSession) waitForConnection(stopChan chan struct{}) bool {
+	connected := make(chan bool, 1)
+
+	go func() {
+		_, err := io.ReadFull(r.stdout, make([]byte, 1))
+		connected <- err == nil
+	}()
+
+	select {
+	case <-stopChan:
+		return false
+	case ok := <-connected:
+		return ok
+	}
+}
+
+// tunnel connects the accepted connection with the local port until one of them is closed
+func (r *reverseSession) tunnel(localPort string, stopChan chan struct{}) error {
+	defer r.close()
+
+	conn, err := net.Dial("tcp", "localhost:"+localPort)
+	if err != nil {
+		return err
+	}
+
+	defer conn.Close()
+
+	copyDone := make(chan struct{}, 2)
+
+	go func() {
+		io.Copy(conn, r.stdout)
+		copyDone <- struct{}{}
+	}()
+
+	go func() {
+		io.Copy(r.stdin, conn)
+		copyDone <- struct{}{}
+	}()
+
+	select {
+	case <-stopChan:
+	case <-copyDone:
+	}
+
+	return nil
+}
+
+func (r *reverseSession) close() {
+	r.stdin.Close()
+	r.stdout.Close()
+}
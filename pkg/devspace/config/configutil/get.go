@@ -14,6 +14,7 @@ type ConfigInterface interface{}
 
 const configGitignore = `logs/
 overwrite.yaml
+build-cache.yaml
 `
 
 const configPath = "/.devspace/config.yaml"
@@ -1,5 +1,10 @@
 package v1
 
+import (
+	"fmt"
+	"strconv"
+)
+
 //DevSpaceConfig defines the devspace deployment
 type DevSpaceConfig struct {
 	Release        *Release                 `yaml:"release"`
@@ -9,16 +14,67 @@ type DevSpaceConfig struct {
 
 //PortForwardingConfig defines the ports for a port forwarding to a DevSpace
 type PortForwardingConfig struct {
-	Namespace     *string             `yaml:"namespace"`
-	ResourceType  *string             `yaml:"resourceType"`
-	LabelSelector *map[string]*string `yaml:"labelSelector"`
-	PortMappings  *[]*PortMapping     `yaml:"portMappings"`
+	Name                *string             `yaml:"name"`
+	Namespace           *string             `yaml:"namespace"`
+	ResourceType        *string             `yaml:"resourceType"`
+	ResourceName        *string             `yaml:"resourceName"`
+	LabelSelector       *map[string]*string `yaml:"labelSelector"`
+	ContainerName       *string             `yaml:"containerName"`
+	PortMappings        *[]*PortMapping     `yaml:"portMappings"`
+	ReversePortMappings *[]*PortMapping     `yaml:"reversePortMappings"`
 }
 
 //PortMapping defines the ports for a PortMapping
 type PortMapping struct {
-	LocalPort  *int `yaml:"localPort"`
-	RemotePort *int `yaml:"remotePort"`
+	LocalPort   *LocalPort   `yaml:"localPort"`
+	RemotePort  *int         `yaml:"remotePort"`
+	BindAddress *string      `yaml:"bindAddress"`
+	HealthCheck *HealthCheck `yaml:"healthCheck"`
+	Inspect     *bool        `yaml:"inspect"`
+}
+
+//HealthCheck defines how a forwarded local port is probed
+type HealthCheck struct {
+	Type     *string `yaml:"type"`
+	Path     *string `yaml:"path"`
+	Interval *int    `yaml:"interval"`
+}
+
+//LocalPort is either a port number or "auto" to pick a free port
+type LocalPort string
+
+//LocalPortAuto lets the DevSpace CLI pick a free local port
+const LocalPortAuto LocalPort = "auto"
+
+//NewLocalPort creates a local port from a port number
+func NewLocalPort(port int) *LocalPort {
+	localPort := LocalPort(strconv.Itoa(port))
+	return &localPort
+}
+
+//IsAuto returns true if a free local port should be picked
+func (l LocalPort) IsAuto() bool {
+	return l == LocalPortAuto
+}
+
+//Int returns the port number, it fails for "auto"
+func (l LocalPort) Int() (int, error) {
+	port, err := strconv.Atoi(string(l))
+	if err != nil || port <= 0 || port > 65535 {
+		return 0, fmt.Errorf("Invalid local port %s (must be a port number or %s)", string(l), LocalPortAuto)
+	}
+
+	return port, nil
+}
+
+//MarshalYAML writes port numbers as numbers instead of strings
+func (l LocalPort) MarshalYAML() (interface{}, error) {
+	port, err := l.Int()
+	if err != nil {
+		return string(l), nil
+	}
+
+	return port, nil
 }
 
 //SyncConfig defines the paths for a SyncFolder
@@ -31,4 +87,5 @@ type SyncConfig struct {
 	ExcludePaths         *[]string           `yaml:"excludePaths"`
 	DownloadExcludePaths *[]string           `yaml:"downloadExcludePaths"`
 	UploadExcludePaths   *[]string           `yaml:"uploadExcludePaths"`
+	TransferWorkers      *int                `yaml:"transferWorkers"`
 }
@@ -2,40 +2,98 @@ package v1
 
 //ImageConfig defines the image specification
 type ImageConfig struct {
-	Name     *string      `yaml:"name"`
-	Tag      *string      `yaml:"tag"`
-	Registry *string      `yaml:"registry"`
-	Build    *BuildConfig `yaml:"build"`
+	Name           *string      `yaml:"name"`
+	Tag            *string      `yaml:"tag"`
+	TagStrategy    *string      `yaml:"tagStrategy"`
+	Digest         *string      `yaml:"digest"`
+	DeployByDigest *bool        `yaml:"deployByDigest"`
+	Registry       *string      `yaml:"registry"`
+	Build          *BuildConfig `yaml:"build"`
 }
 
 //BuildConfig defines the build process for an image
 type BuildConfig struct {
-	ContextPath     *string       `yaml:"contextPath"`
-	DockerfilePath  *string       `yaml:"dockerfilePath"`
-	Engine          *BuildEngine  `yaml:"engine"`
-	LatestTimestamp *string       `yaml:"latestTimestamp"`
-	Options         *BuildOptions `yaml:"options"`
+	Disabled       *bool         `yaml:"disabled"`
+	ContextPath    *string       `yaml:"contextPath"`
+	DockerfilePath *string       `yaml:"dockerfilePath"`
+	Engine         *BuildEngine  `yaml:"engine"`
+	BuildHash      *string       `yaml:"buildHash"`
+	Options        *BuildOptions `yaml:"options"`
+	Watch          *bool         `yaml:"watch"`
+	RebuildOn      *[]string     `yaml:"rebuildOn"`
 }
 
 //BuildEngine defines which build engine to use
 type BuildEngine struct {
-	Kaniko *KanikoBuildEngine `yaml:"kaniko"`
-	Docker *DockerBuildEngine `yaml:"docker"`
+	Kaniko   *KanikoBuildEngine   `yaml:"kaniko"`
+	Buildkit *BuildkitBuildEngine `yaml:"buildkit"`
+	Docker   *DockerBuildEngine   `yaml:"docker"`
 }
 
 //KanikoBuildEngine tells the DevSpace CLI to build with Docker on Minikube or on localhost
 type KanikoBuildEngine struct {
+	Enabled        *bool                 `yaml:"enabled"`
+	Namespace      *string               `yaml:"namespace"`
+	Cache          *bool                 `yaml:"cache"`
+	CacheRepo      *string               `yaml:"cacheRepo"`
+	Image          *string               `yaml:"image"`
+	Resources      *ResourceRequirements `yaml:"resources"`
+	NodeSelector   *map[string]*string   `yaml:"nodeSelector"`
+	Tolerations    *[]*Toleration        `yaml:"tolerations"`
+	ServiceAccount *string               `yaml:"serviceAccount"`
+	Annotations    *map[string]*string   `yaml:"annotations"`
+	StartTimeout   *int                  `yaml:"startTimeout"`
+}
+
+//ResourceRequirements defines the resource requests and limits of a container (e.g. cpu: 500m, memory: 1Gi)
+type ResourceRequirements struct {
+	Requests *map[string]*string `yaml:"requests"`
+	Limits   *map[string]*string `yaml:"limits"`
+}
+
+//Toleration defines a toleration of a pod for a node taint
+type Toleration struct {
+	Key               *string `yaml:"key"`
+	Operator          *string `yaml:"operator"`
+	Value             *string `yaml:"value"`
+	Effect            *string `yaml:"effect"`
+	TolerationSeconds *int64  `yaml:"tolerationSeconds"`
+}
+
+//BuildkitBuildEngine tells the DevSpace CLI to build with BuildKit inside the Kubernetes cluster
+type BuildkitBuildEngine struct {
 	Enabled   *bool   `yaml:"enabled"`
 	Namespace *string `yaml:"namespace"`
+	Image     *string `yaml:"image"`
+	Address   *string `yaml:"address"`
+	Cache     *bool   `yaml:"cache"`
+	CacheRepo *string `yaml:"cacheRepo"`
 }
 
 //DockerBuildEngine tells the DevSpace CLI to build with Docker on Minikube or on localhost
 type DockerBuildEngine struct {
-	Enabled        *bool `yaml:"enabled"`
-	PreferMinikube *bool `yaml:"preferMinikube"`
+	Enabled        *bool     `yaml:"enabled"`
+	PreferMinikube *bool     `yaml:"preferMinikube"`
+	LocalContexts  *[]string `yaml:"localContexts"`
 }
 
 //BuildOptions defines options for building Docker images
 type BuildOptions struct {
-	BuildArgs *map[string]*string `yaml:"buildArgs"`
+	BuildArgs  *map[string]*string      `yaml:"buildArgs"`
+	Target     *string                  `yaml:"target"`
+	Labels     *map[string]*string      `yaml:"labels"`
+	Network    *string                  `yaml:"network"`
+	CacheFrom  *[]string                `yaml:"cacheFrom"`
+	NoCache    *bool                    `yaml:"noCache"`
+	Pull       *bool                    `yaml:"pull"`
+	ExtraHosts *[]string                `yaml:"extraHosts"`
+	Squash     *bool                    `yaml:"squash"`
+	Secrets    *map[string]*BuildSecret `yaml:"secrets"`
+	SSH        *string                  `yaml:"ssh"`
+}
+
+//BuildSecret defines where the content of a build secret is read from, either a local file or an environment variable
+type BuildSecret struct {
+	File *string `yaml:"file"`
+	Env  *string `yaml:"env"`
 }
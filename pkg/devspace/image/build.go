@@ -0,0 +1,574 @@
+package image
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/covexo/devspace/pkg/devspace/builder"
+	"github.com/covexo/devspace/pkg/devspace/builder/buildkit"
+	"github.com/covexo/devspace/pkg/devspace/builder/docker"
+	"github.com/covexo/devspace/pkg/devspace/builder/kaniko"
+	"github.com/covexo/devspace/pkg/devspace/clients/kubectl"
+	"github.com/covexo/devspace/pkg/devspace/config/configutil"
+	"github.com/covexo/devspace/pkg/devspace/config/v1"
+	"github.com/covexo/devspace/pkg/devspace/registry"
+	"github.com/covexo/devspace/pkg/util/log"
+	"k8s.io/client-go/kubernetes"
+)
+
+// DefaultParallelism is the default number of images that are built at the same time
+const DefaultParallelism = 4
+
+// BuildOptions defines which images are built and how
+type BuildOptions struct {
+	// Workdir is the directory the context and Dockerfile paths are relative to
+	Workdir string
+
+	// ImageNames are the names of the images in the config that are built, all images if empty
+	ImageNames []string
+
+	// Force builds the images even if the build context didn't change or a matching build is in the build cache
+	Force bool
+
+	// NoPush only builds the images. The tags are not saved, because the images are not in the registry
+	NoPush bool
+
+	// Tag is used instead of the tag strategy of the images
+	Tag string
+
+	// Parallelism is the maximum number of images that are built at the same time
+	Parallelism int
+
+	// Kubectl is used by the in-cluster engines. It is created when needed if nil
+	Kubectl *kubernetes.Clientset
+
+	// Log is the logger for the build output, the global logger if nil
+	Log log.Logger
+}
+
+// BuildResult holds the outcome of an image build
+type BuildResult struct {
+	ImageName string  `json:"image"`
+	Name      string  `json:"name"`
+	Tag       string  `json:"tag"`
+	Digest    string  `json:"digest"`
+	Built     bool    `json:"built"`
+	Cached    bool    `json:"cached"`
+	Duration  float64 `json:"duration"`
+}
+
+// buildPaths holds the absolute paths of the build context and the Dockerfile of an image
+type buildPaths struct {
+	contextPath    string
+	dockerfilePath string
+}
+
+// BuildImages builds and pushes the images whose build context changed and saves the new tags. If the
+// same build context was pushed before and the tag still exists in the registry, the tag is reused
+// instead. It returns a result for every selected image, ordered by image name
+func BuildImages(options *BuildOptions) ([]*BuildResult, error) {
+	config := configutil.GetConfig(false)
+	buildLog := options.Log
+	if buildLog == nil {
+		buildLog = log.GetInstance()
+	}
+
+	imageNames, err := getImageNames(config, options.ImageNames)
+	if err != nil {
+		return nil, err
+	}
+
+	err = ValidateImages(config, options.Workdir)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*BuildResult, 0, len(imageNames))
+	resultMap := map[string]*BuildResult{}
+	buildNames := []string{}
+	paths := map[string]*buildPaths{}
+	buildCache := LoadBuildCache(options.Workdir)
+	cacheableImages := map[string]bool{}
+	imageTags := map[string]string{}
+	imageDigests := map[string]string{}
+	buildHashes := map[string]string{}
+
+	for _, imageName := range imageNames {
+		imageConf := (*config.Images)[imageName]
+
+		result := &BuildResult{
+			ImageName: imageName,
+			Name:      registry.GetImageURL(imageConf, false),
+		}
+		if imageConf.Tag != nil {
+			result.Tag = *imageConf.Tag
+		}
+		if imageConf.Digest != nil {
+			result.Digest = *imageConf.Digest
+		}
+
+		results = append(results, result)
+		resultMap[imageName] = result
+
+		if IsBuildDisabled(imageConf) {
+			buildLog.Infof("Skip building image '%s' (build disabled)", imageName)
+			continue
+		}
+
+		imagePaths := getBuildPaths(options.Workdir, imageConf)
+
+		mustRebuild, buildHash, err := ShouldRebuild(imageConf, imagePaths.contextPath, imagePaths.dockerfilePath, options.Force)
+		if err != nil {
+			return nil, fmt.Errorf("Image '%s': %v", imageName, err)
+		}
+
+		if mustRebuild == false {
+			buildLog.Infof("Skip building image '%s'", imageName)
+			continue
+		}
+
+		// Builds with a fixed tag or without push are not cached, images of the cluster's docker
+		// daemon are not in a registry
+		if options.Tag == "" && options.NoPush == false && IsLocalImage(imageConf) == false {
+			cacheableImages[imageName] = true
+
+			if options.Force == false {
+				cachedBuild := getCachedBuild(buildCache, imageConf, buildHash, result.Name, buildLog)
+				if cachedBuild != nil {
+					buildLog.Donef("Skip building image '%s', reusing tag %s that was pushed for the same build context", imageName, cachedBuild.Tag)
+
+					imageTags[imageName] = cachedBuild.Tag
+					imageDigests[imageName] = cachedBuild.Digest
+					buildHashes[imageName] = buildHash
+
+					result.Tag = cachedBuild.Tag
+					result.Digest = cachedBuild.Digest
+					result.Cached = true
+					continue
+				}
+			}
+		}
+
+		buildNames = append(buildNames, imageName)
+		paths[imageName] = imagePaths
+		buildHashes[imageName] = buildHash
+	}
+
+	if len(buildNames) == 0 && len(imageTags) == 0 {
+		return results, nil
+	}
+
+	if options.Kubectl == nil && needsKubectl(config, buildNames) {
+		options.Kubectl, err = kubectl.NewClient()
+		if err != nil {
+			return nil, fmt.Errorf("Unable to create new kubectl client: %v", err)
+		}
+	}
+
+	parallelism := options.Parallelism
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	// The output of concurrent builds is prefixed with the image name
+	prefixOutput := parallelism > 1 && len(buildNames) > 1
+	if prefixOutput {
+		buildLog.Infof("Building %d images (%d in parallel)", len(buildNames), parallelism)
+	}
+
+	buildMutex := sync.Mutex{}
+	buildErrors := map[string]error{}
+
+	waitGroup := sync.WaitGroup{}
+	semaphore := make(chan bool, parallelism)
+
+	for _, imageName := range buildNames {
+		waitGroup.Add(1)
+
+		go func(imageName string) {
+			defer waitGroup.Done()
+
+			semaphore <- true
+			defer func() { <-semaphore }()
+
+			imageLog := buildLog
+			if prefixOutput {
+				imageLog = log.NewPrefixLogger("["+imageName+"] ", buildLog)
+			}
+
+			start := time.Now()
+			imageTag, imageDigest, err := buildImage(imageName, (*config.Images)[imageName], buildHashes[imageName], paths[imageName], options, imageLog)
+
+			buildMutex.Lock()
+			defer buildMutex.Unlock()
+
+			if err != nil {
+				buildErrors[imageName] = err
+			} else {
+				imageTags[imageName] = imageTag
+				imageDigests[imageName] = imageDigest
+
+				resultMap[imageName].Tag = imageTag
+				resultMap[imageName].Digest = imageDigest
+				resultMap[imageName].Built = true
+				resultMap[imageName].Duration = time.Since(start).Seconds()
+			}
+		}(imageName)
+	}
+
+	waitGroup.Wait()
+
+	if len(buildErrors) > 0 {
+		for _, imageName := range buildNames {
+			if buildErrors[imageName] != nil {
+				buildLog.Errorf("Error building image '%s': %v", imageName, buildErrors[imageName])
+			}
+		}
+
+		return nil, fmt.Errorf("%d of %d image builds failed", len(buildErrors), len(buildNames))
+	}
+
+	// Images that were not pushed can't be deployed, so the config stays unchanged
+	if options.NoPush {
+		return results, nil
+	}
+
+	// The new tags and build hashes are only saved if all builds succeeded. An empty digest removes the
+	// digest of the previous build, e.g. for images that were not pushed
+	for imageName, imageTag := range imageTags {
+		if cacheableImages[imageName] && resultMap[imageName].Built {
+			buildCache.Add(resultMap[imageName].Name, buildHashes[imageName], imageTag, imageDigests[imageName])
+		}
+
+		tag := imageTag
+		buildHash := buildHashes[imageName]
+		(*config.Images)[imageName].Tag = &tag
+		(*config.Images)[imageName].Build.BuildHash = &buildHash
+
+		if imageDigests[imageName] != "" {
+			digest := imageDigests[imageName]
+			(*config.Images)[imageName].Digest = &digest
+		} else {
+			(*config.Images)[imageName].Digest = nil
+		}
+	}
+
+	err = configutil.SaveConfig()
+	if err != nil {
+		return nil, fmt.Errorf("Config saving error: %v", err)
+	}
+
+	err = buildCache.Save(options.Workdir)
+	if err != nil {
+		buildLog.Warnf("Error saving build cache: %v", err)
+	}
+
+	return results, nil
+}
+
+// getCachedBuild returns the cached build for the current build hash of the image if its tag still
+// exists in the registry. Builds whose tag was deleted are removed from the cache
+func getCachedBuild(buildCache *BuildCache, imageConf *v1.ImageConfig, buildHash, imageURL string, buildLog log.Logger) *CachedBuild {
+	cachedBuild := buildCache.Get(imageURL, buildHash)
+	if cachedBuild == nil {
+		return nil
+	}
+
+	registryConf, err := registry.GetRegistryConfig(imageConf)
+	if err != nil {
+		return nil
+	}
+
+	exists, err := registry.NewClientFromConfig(registryConf).ManifestExists(*imageConf.Name, cachedBuild.Tag)
+	if err != nil {
+		buildLog.Warnf("Unable to check if %s:%s still exists, rebuilding: %v", imageURL, cachedBuild.Tag, err)
+		return nil
+	}
+
+	if exists == false {
+		buildCache.Remove(imageURL, buildHash)
+		return nil
+	}
+
+	return cachedBuild
+}
+
+// getImageNames returns the sorted names of the selected images and fails for unknown names
+func getImageNames(config *v1.Config, selectedNames []string) ([]string, error) {
+	if config.Images == nil {
+		return nil, errors.New("No images configured")
+	}
+
+	imageNames := []string{}
+
+	if len(selectedNames) == 0 {
+		for imageName := range *config.Images {
+			imageNames = append(imageNames, imageName)
+		}
+	} else {
+		for _, imageName := range selectedNames {
+			if _, ok := (*config.Images)[imageName]; ok == false {
+				return nil, fmt.Errorf("Image '%s' not found in config", imageName)
+			}
+
+			imageNames = append(imageNames, imageName)
+		}
+	}
+
+	sort.Strings(imageNames)
+
+	return imageNames, nil
+}
+
+func getBuildPaths(workdir string, imageConf *v1.ImageConfig) *buildPaths {
+	dockerfilePath := "./Dockerfile"
+	contextPath := "./"
+
+	if imageConf.Build.DockerfilePath != nil {
+		dockerfilePath = *imageConf.Build.DockerfilePath
+	}
+
+	if imageConf.Build.ContextPath != nil {
+		contextPath = *imageConf.Build.ContextPath
+	}
+
+	return &buildPaths{
+		contextPath:    filepath.Join(workdir, strings.TrimPrefix(contextPath, ".")),
+		dockerfilePath: filepath.Join(workdir, strings.TrimPrefix(dockerfilePath, ".")),
+	}
+}
+
+// needsKubectl returns true if one of the images is built inside the cluster
+func needsKubectl(config *v1.Config, imageNames []string) bool {
+	for _, imageName := range imageNames {
+		engine := getBuildEngine((*config.Images)[imageName])
+
+		if engine.Kaniko != nil || engine.Buildkit != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ShouldRebuild returns true if the build context, the Dockerfile, the build options or the engine changed
+// since the latest build or if force is true. It also returns the new build hash, which the caller saves in the
+// image config together with the new tag once the build succeeded
+func ShouldRebuild(imageConf *v1.ImageConfig, contextPath, dockerfilePath string, force bool) (bool, string, error) {
+	// Without the Dockerfile the image is never rebuilt, so a stored build hash is no reason to skip this error
+	_, err := os.Stat(dockerfilePath)
+	if err != nil {
+		return false, "", fmt.Errorf("Dockerfile %s missing: %v", dockerfilePath, err)
+	}
+
+	buildHash, err := builder.GetBuildHash(contextPath, dockerfilePath, GetBuildOptions(imageConf), getBuildEngineName(getBuildEngine(imageConf)))
+	if err != nil {
+		return false, "", fmt.Errorf("Error hashing build context: %v", err)
+	}
+
+	mustRebuild := force || imageConf.Build.BuildHash == nil || *imageConf.Build.BuildHash != buildHash
+
+	return mustRebuild, buildHash, nil
+}
+
+// buildImage builds and pushes a single image and returns the new tag and the digest of the pushed manifest.
+// The digest is empty for images that were not pushed
+func buildImage(imageName string, imageConf *v1.ImageConfig, buildHash string, paths *buildPaths, options *BuildOptions, buildLog log.Logger) (string, string, error) {
+	config := configutil.GetConfig(false)
+
+	imageTag := options.Tag
+	if imageTag == "" {
+		tagStrategy := builder.DefaultTagStrategy
+		if imageConf.TagStrategy != nil {
+			tagStrategy = *imageConf.TagStrategy
+		}
+
+		var err error
+
+		imageTag, err = builder.GetImageTag(tagStrategy, paths.contextPath, buildHash)
+		if err != nil {
+			return "", "", fmt.Errorf("Image building failed: %v", err)
+		}
+	}
+
+	registryConf, err := registry.GetRegistryConfig(imageConf)
+	if err != nil {
+		return "", "", err
+	}
+
+	buildSecrets, err := GetBuildSecrets(imageConf, options.Workdir)
+	if err != nil {
+		return "", "", fmt.Errorf("Error reading build secrets: %v", err)
+	}
+
+	var imageBuilder builder.Interface
+
+	// Only the docker engine can build with the docker daemon of the cluster node
+	isLocalImage := false
+
+	buildInfo := "Building image '%s' with engine '%s'"
+	engineName := ""
+	registryURL := ""
+
+	if registryConf.URL != nil {
+		registryURL = *registryConf.URL
+	}
+	if registryURL == "hub.docker.com" {
+		registryURL = ""
+	}
+
+	engine := getBuildEngine(imageConf)
+
+	if engine.Kaniko != nil {
+		engineName = "kaniko"
+		buildNamespace := *config.DevSpace.Release.Namespace
+		allowInsecurePush := false
+
+		if options.NoPush {
+			return "", "", errors.New("The kaniko engine always pushes the image and can't be used without push")
+		}
+
+		if engine.Kaniko.Namespace != nil {
+			buildNamespace = *engine.Kaniko.Namespace
+		}
+
+		if registryConf.Insecure != nil {
+			allowInsecurePush = *registryConf.Insecure
+		}
+		imageBuilder, err = kaniko.NewBuilder(registryURL, *imageConf.Name, imageTag, buildNamespace, engine.Kaniko, options.Kubectl, allowInsecurePush, buildLog)
+		if err != nil {
+			return "", "", fmt.Errorf("Error creating kaniko builder: %v", err)
+		}
+	} else if engine.Buildkit != nil {
+		engineName = "buildkit"
+		buildNamespace := *config.DevSpace.Release.Namespace
+		allowInsecurePush := false
+
+		if options.NoPush {
+			return "", "", errors.New("The buildkit engine always pushes the image and can't be used without push")
+		}
+
+		if engine.Buildkit.Namespace != nil {
+			buildNamespace = *engine.Buildkit.Namespace
+		}
+
+		if registryConf.Insecure != nil {
+			allowInsecurePush = *registryConf.Insecure
+		}
+		imageBuilder, err = buildkit.NewBuilder(registryURL, *imageConf.Name, imageTag, buildNamespace, engine.Buildkit, options.Kubectl, allowInsecurePush, buildLog)
+		if err != nil {
+			return "", "", fmt.Errorf("Error creating buildkit builder: %v", err)
+		}
+	} else {
+		engineName = "docker"
+		preferMinikube, localContexts := getDockerEngineOptions(engine)
+
+		dockerBuilder, err := docker.NewBuilder(registryURL, *imageConf.Name, imageTag, preferMinikube, buildLog)
+		if err != nil {
+			return "", "", fmt.Errorf("Error creating docker client: %v", err)
+		}
+
+		imageBuilder = dockerBuilder
+		isLocalImage = dockerBuilder.SharesClusterDaemon(localContexts)
+	}
+
+	buildLog.Infof(buildInfo, imageName, engineName)
+
+	// Images built by the docker daemon of the cluster node are available without a registry
+	if options.NoPush || isLocalImage {
+		err = imageBuilder.BuildImage(paths.contextPath, paths.dockerfilePath, GetBuildOptions(imageConf), buildSecrets)
+		if err != nil {
+			return "", "", fmt.Errorf("Error during image build: %v", err)
+		}
+
+		if options.NoPush {
+			buildLog.Done("Done building image '" + imageName + "' (skipped push)")
+		} else {
+			buildLog.Done("Done building image '" + imageName + "' (skipped push, because the cluster uses the same Docker daemon)")
+		}
+
+		return imageTag, "", nil
+	}
+
+	username := ""
+	password := ""
+
+	if registryConf.URL != nil {
+		registryURL = *registryConf.URL
+	}
+	if registryConf.Auth != nil {
+		if registryConf.Auth.Username != nil {
+			username = *registryConf.Auth.Username
+		}
+
+		if registryConf.Auth.Password != nil {
+			password = *registryConf.Auth.Password
+		}
+	}
+
+	buildLog.StartWait("Authenticating (" + registryURL + ")")
+	_, err = imageBuilder.Authenticate(username, password, len(username) == 0)
+	buildLog.StopWait()
+
+	if err != nil {
+		return "", "", fmt.Errorf("Error during image registry authentication: %v", err)
+	}
+
+	buildLog.Done("Authentication successful (" + registryURL + ")")
+
+	err = imageBuilder.BuildImage(paths.contextPath, paths.dockerfilePath, GetBuildOptions(imageConf), buildSecrets)
+	if err != nil {
+		return "", "", fmt.Errorf("Error during image build: %v", err)
+	}
+
+	imageDigest, err := imageBuilder.PushImage()
+	if err != nil {
+		return "", "", fmt.Errorf("Error during image push: %v", err)
+	}
+
+	buildLog.Info("Image pushed to registry (" + registryURL + ")")
+	buildLog.Done("Done building and pushing image '" + imageName + "'")
+
+	return imageTag, imageDigest, nil
+}
+
+// IsLocalImage returns true if the image is built with the docker engine by the docker daemon
+// of the cluster node (e.g. minikube or docker-desktop), so it doesn't need to be pushed
+func IsLocalImage(imageConf *v1.ImageConfig) bool {
+	if IsBuildDisabled(imageConf) {
+		return false
+	}
+
+	engine := getBuildEngine(imageConf)
+	if engine.Kaniko != nil || engine.Buildkit != nil {
+		return false
+	}
+
+	return docker.SharesClusterDaemon(getDockerEngineOptions(engine))
+}
+
+// getBuildEngine returns the engine config of the image. Without engine config, the image is built with docker
+func getBuildEngine(imageConf *v1.ImageConfig) *v1.BuildEngine {
+	if imageConf.Build.Engine == nil {
+		return &v1.BuildEngine{}
+	}
+
+	return imageConf.Build.Engine
+}
+
+// getBuildEngineName returns the name of the engine that builds the image
+func getBuildEngineName(engine *v1.BuildEngine) string {
+	if engine.Kaniko != nil {
+		return "kaniko"
+	} else if engine.Buildkit != nil {
+		return "buildkit"
+	}
+
+	return "docker"
+}
@@ -0,0 +1,102 @@
+package image
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/covexo/devspace/pkg/util/yamlutil"
+)
+
+// BuildCachePath is the path of the build cache relative to the workdir
+const BuildCachePath = ".devspace/build-cache.yaml"
+
+// maxCachedBuilds is the number of pushed builds that are remembered per image
+const maxCachedBuilds = 20
+
+// BuildCache remembers which tag was pushed for which build hash, so that an image doesn't have to be
+// rebuilt if the same sources were built and pushed before (e.g. after switching back to a branch)
+type BuildCache struct {
+	// Images maps the image name including the registry to the pushed builds by build hash
+	Images map[string]map[string]*CachedBuild `yaml:"images"`
+}
+
+// CachedBuild is a build that was pushed to the registry
+type CachedBuild struct {
+	Tag    string `yaml:"tag"`
+	Digest string `yaml:"digest,omitempty"`
+	Pushed int64  `yaml:"pushed"`
+}
+
+// LoadBuildCache reads the build cache from the workdir. A missing or invalid cache results in an empty cache
+func LoadBuildCache(workdir string) *BuildCache {
+	cache := &BuildCache{}
+
+	err := yamlutil.ReadYamlFromFile(filepath.Join(workdir, BuildCachePath), cache)
+	if err != nil || cache.Images == nil {
+		cache.Images = map[string]map[string]*CachedBuild{}
+	}
+
+	return cache
+}
+
+// Save writes the build cache to the workdir
+func (c *BuildCache) Save(workdir string) error {
+	cachePath := filepath.Join(workdir, BuildCachePath)
+
+	err := os.MkdirAll(filepath.Dir(cachePath), 0755)
+	if err != nil {
+		return err
+	}
+
+	return yamlutil.WriteYamlToFile(c, cachePath)
+}
+
+// Get returns the build that was pushed for the build hash or nil
+func (c *BuildCache) Get(imageName, buildHash string) *CachedBuild {
+	if c.Images[imageName] == nil {
+		return nil
+	}
+
+	return c.Images[imageName][buildHash]
+}
+
+// Add remembers a pushed build and forgets the oldest builds of the image if there are too many
+func (c *BuildCache) Add(imageName, buildHash, tag, digest string) {
+	builds := c.Images[imageName]
+	if builds == nil {
+		builds = map[string]*CachedBuild{}
+		c.Images[imageName] = builds
+	}
+
+	builds[buildHash] = &CachedBuild{
+		Tag:    tag,
+		Digest: digest,
+		Pushed: time.Now().Unix(),
+	}
+
+	if len(builds) <= maxCachedBuilds {
+		return
+	}
+
+	buildHashes := make([]string, 0, len(builds))
+	for hash := range builds {
+		buildHashes = append(buildHashes, hash)
+	}
+
+	sort.Slice(buildHashes, func(i, j int) bool {
+		return builds[buildHashes[i]].Pushed > builds[buildHashes[j]].Pushed
+	})
+
+	for _, hash := range buildHashes[maxCachedBuilds:] {
+		delete(builds, hash)
+	}
+}
+
+// Remove forgets a build, e.g. because its tag was deleted from the registry
+func (c *BuildCache) Remove(imageName, buildHash string) {
+	if c.Images[imageName] != nil {
+		delete(c.Images[imageName], buildHash)
+	}
+}
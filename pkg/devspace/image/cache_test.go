@@ -0,0 +1,68 @@
+package image
+
+import (
+	"io/ioutil"
+	"os"
+	"strconv"
+	"testing"
+)
+
+func TestBuildCache(t *testing.T) {
+	workdir, err := ioutil.TempDir("", "devspace-build-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(workdir)
+
+	buildCache := LoadBuildCache(workdir)
+	if buildCache.Get("registry.example.com/app", "hash1") != nil {
+		t.Fatal("Expected an empty cache")
+	}
+
+	buildCache.Add("registry.example.com/app", "hash1", "abc", "sha256:123")
+	buildCache.Add("registry.example.com/app", "hash2", "def", "")
+
+	err = buildCache.Save(workdir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buildCache = LoadBuildCache(workdir)
+
+	cachedBuild := buildCache.Get("registry.example.com/app", "hash1")
+	if cachedBuild == nil || cachedBuild.Tag != "abc" || cachedBuild.Digest != "sha256:123" {
+		t.Fatalf("Unexpected cached build %v", cachedBuild)
+	}
+	if buildCache.Get("registry.example.com/other", "hash1") != nil {
+		t.Fatal("Expected builds to be cached per image")
+	}
+
+	buildCache.Remove("registry.example.com/app", "hash1")
+	if buildCache.Get("registry.example.com/app", "hash1") != nil {
+		t.Fatal("Expected the build to be removed")
+	}
+}
+
+func TestBuildCacheEviction(t *testing.T) {
+	buildCache := &BuildCache{
+		Images: map[string]map[string]*CachedBuild{},
+	}
+
+	for i := 0; i < maxCachedBuilds; i++ {
+		buildCache.Add("app", "hash"+strconv.Itoa(i), "tag"+strconv.Itoa(i), "")
+		buildCache.Images["app"]["hash"+strconv.Itoa(i)].Pushed = int64(i)
+	}
+
+	buildCache.Add("app", "new", "new", "")
+
+	if len(buildCache.Images["app"]) != maxCachedBuilds {
+		t.Fatalf("Expected %d cached builds, got %d", maxCachedBuilds, len(buildCache.Images["app"]))
+	}
+	if buildCache.Get("app", "hash0") != nil {
+		t.Fatal("Expected the oldest build to be evicted")
+	}
+	if buildCache.Get("app", "new") == nil || buildCache.Get("app", "hash1") == nil {
+		t.Fatal("Expected the newer builds to be kept")
+	}
+}
@@ -0,0 +1,166 @@
+package image
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/covexo/devspace/pkg/devspace/builder"
+	"github.com/covexo/devspace/pkg/devspace/config/v1"
+	"github.com/docker/docker/api/types"
+	homedir "github.com/mitchellh/go-homedir"
+)
+
+// secretIDRegEx matches the secret ids that can be used as key of a kubernetes secret
+var secretIDRegEx = regexp.MustCompile(`^[-._a-zA-Z0-9]+$`)
+
+// sshDefaultAgent forwards the ssh agent of $SSH_AUTH_SOCK
+const sshDefaultAgent = "default"
+
+// GetBuildOptions translates the configured build options into the options for the builders
+func GetBuildOptions(imageConf *v1.ImageConfig) *types.ImageBuildOptions {
+	buildOptions := &types.ImageBuildOptions{}
+	options := imageConf.Build.Options
+
+	if options == nil {
+		return buildOptions
+	}
+
+	if options.BuildArgs != nil {
+		buildOptions.BuildArgs = *options.BuildArgs
+	}
+	if options.Target != nil {
+		buildOptions.Target = *options.Target
+	}
+	if options.Labels != nil {
+		buildOptions.Labels = map[string]string{}
+
+		for key, value := range *options.Labels {
+			if value != nil {
+				buildOptions.Labels[key] = *value
+			}
+		}
+	}
+	if options.Network != nil {
+		buildOptions.NetworkMode = *options.Network
+	}
+	if options.CacheFrom != nil {
+		buildOptions.CacheFrom = *options.CacheFrom
+	}
+	if options.NoCache != nil {
+		buildOptions.NoCache = *options.NoCache
+	}
+	if options.Pull != nil {
+		buildOptions.PullParent = *options.Pull
+	}
+	if options.ExtraHosts != nil {
+		buildOptions.ExtraHosts = *options.ExtraHosts
+	}
+	if options.Squash != nil {
+		buildOptions.Squash = *options.Squash
+	}
+
+	return buildOptions
+}
+
+// getDockerEngineOptions returns preferMinikube and the local contexts of the docker engine. A missing
+// docker engine config is treated like an empty one, i.e. minikube is preferred
+func getDockerEngineOptions(engine *v1.BuildEngine) (bool, []string) {
+	preferMinikube := true
+	localContexts := []string{}
+
+	if engine.Docker != nil {
+		if engine.Docker.PreferMinikube != nil {
+			preferMinikube = *engine.Docker.PreferMinikube
+		}
+		if engine.Docker.LocalContexts != nil {
+			localContexts = *engine.Docker.LocalContexts
+		}
+	}
+
+	return preferMinikube, localContexts
+}
+
+// GetBuildSecrets reads the configured build secrets from their files and environment variables. Relative
+// paths are resolved against the workdir. It returns nil if neither secrets nor ssh are configured
+func GetBuildSecrets(imageConf *v1.ImageConfig, workdir string) (*builder.BuildSecrets, error) {
+	options := imageConf.Build.Options
+	if options == nil || (options.Secrets == nil && options.SSH == nil) {
+		return nil, nil
+	}
+
+	buildSecrets := &builder.BuildSecrets{
+		Secrets: map[string][]byte{},
+	}
+
+	if options.Secrets != nil {
+		for id, secret := range *options.Secrets {
+			if secretIDRegEx.MatchString(id) == false {
+				return nil, fmt.Errorf("Invalid secret id '%s': only letters, digits, '-', '_' and '.' are allowed", id)
+			}
+
+			data, err := getSecretData(secret, workdir)
+			if err != nil {
+				return nil, fmt.Errorf("Secret '%s': %v", id, err)
+			}
+
+			buildSecrets.Secrets[id] = data
+		}
+	}
+
+	if options.SSH != nil && *options.SSH != "" {
+		buildSecrets.SSH = []string{}
+
+		if *options.SSH != sshDefaultAgent {
+			for _, path := range strings.Split(*options.SSH, ",") {
+				absolutePath, err := getAbsolutePath(strings.TrimSpace(path), workdir)
+				if err != nil {
+					return nil, fmt.Errorf("Invalid ssh path %s: %v", path, err)
+				}
+
+				buildSecrets.SSH = append(buildSecrets.SSH, absolutePath)
+			}
+		}
+	}
+
+	return buildSecrets, nil
+}
+
+func getSecretData(secret *v1.BuildSecret, workdir string) ([]byte, error) {
+	if secret == nil || (secret.File == nil) == (secret.Env == nil) {
+		return nil, fmt.Errorf("Exactly one of file and env has to be specified")
+	}
+
+	if secret.Env != nil {
+		value, ok := os.LookupEnv(*secret.Env)
+		if ok == false {
+			return nil, fmt.Errorf("Environment variable %s is not set", *secret.Env)
+		}
+
+		return []byte(value), nil
+	}
+
+	path, err := getAbsolutePath(*secret.File, workdir)
+	if err != nil {
+		return nil, err
+	}
+
+	return ioutil.ReadFile(path)
+}
+
+// getAbsolutePath expands ~ to the home directory and resolves relative paths against the workdir
+func getAbsolutePath(path, workdir string) (string, error) {
+	path, err := homedir.Expand(path)
+	if err != nil {
+		return "", err
+	}
+
+	if filepath.IsAbs(path) == false {
+		path = filepath.Join(workdir, path)
+	}
+
+	return path, nil
+}
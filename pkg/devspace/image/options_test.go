@@ -0,0 +1,132 @@
+package image
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/covexo/devspace/pkg/devspace/config/v1"
+)
+
+func TestGetBuildSecrets(t *testing.T) {
+	workdir, err := ioutil.TempDir("", "devspace-secrets")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(workdir)
+
+	err = ioutil.WriteFile(filepath.Join(workdir, ".npmrc"), []byte("//registry.npmjs.org/:_authToken=secret"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	os.Setenv("DEVSPACE_TEST_TOKEN", "token")
+	defer os.Unsetenv("DEVSPACE_TEST_TOKEN")
+
+	file := ".npmrc"
+	env := "DEVSPACE_TEST_TOKEN"
+	ssh := "default"
+
+	imageConf := &v1.ImageConfig{
+		Build: &v1.BuildConfig{
+			Options: &v1.BuildOptions{
+				Secrets: &map[string]*v1.BuildSecret{
+					"npmrc": {File: &file},
+					"token": {Env: &env},
+				},
+				SSH: &ssh,
+			},
+		},
+	}
+
+	buildSecrets, err := GetBuildSecrets(imageConf, workdir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string][]byte{
+		"npmrc": []byte("//registry.npmjs.org/:_authToken=secret"),
+		"token": []byte("token"),
+	}
+
+	if reflect.DeepEqual(buildSecrets.Secrets, expected) == false {
+		t.Fatalf("Expected secrets %v, got %v", expected, buildSecrets.Secrets)
+	}
+	if buildSecrets.SSH == nil || len(buildSecrets.SSH) != 0 {
+		t.Fatalf("Expected the default ssh agent, got %v", buildSecrets.SSH)
+	}
+
+	ssh = "~/.ssh/id_rsa, keys/deploy"
+
+	buildSecrets, err = GetBuildSecrets(imageConf, workdir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(buildSecrets.SSH) != 2 || filepath.IsAbs(buildSecrets.SSH[0]) == false || buildSecrets.SSH[1] != filepath.Join(workdir, "keys/deploy") {
+		t.Fatalf("Expected absolute ssh paths, got %v", buildSecrets.SSH)
+	}
+
+	missingEnv := "DEVSPACE_TEST_MISSING"
+	invalidConfigs := map[string]*v1.BuildSecret{
+		"missing-file":    {File: &missingEnv},
+		"missing-env":     {Env: &missingEnv},
+		"file-and-env":    {File: &file, Env: &env},
+		"neither":         {},
+		"invalid/id":      {File: &file},
+		"invalid id":      {Env: &env},
+		"with-valid.id_2": nil,
+	}
+
+	for id, secret := range invalidConfigs {
+		imageConf.Build.Options.Secrets = &map[string]*v1.BuildSecret{id: secret}
+
+		_, err = GetBuildSecrets(imageConf, workdir)
+		if err == nil {
+			t.Errorf("Expected an error for secret %s", id)
+		}
+	}
+
+	imageConf.Build.Options = &v1.BuildOptions{}
+
+	buildSecrets, err = GetBuildSecrets(imageConf, workdir)
+	if err != nil || buildSecrets != nil {
+		t.Fatalf("Expected no secrets, got %v, %v", buildSecrets, err)
+	}
+}
+
+func TestGetDockerEngineOptions(t *testing.T) {
+	preferMinikube := false
+	localContexts := []string{"kind"}
+
+	testCases := map[string]struct {
+		engine                 *v1.BuildEngine
+		expectedPreferMinikube bool
+		expectedLocalContexts  []string
+	}{
+		"without docker engine config": {
+			engine:                 &v1.BuildEngine{},
+			expectedPreferMinikube: true,
+			expectedLocalContexts:  []string{},
+		},
+		"empty docker engine config": {
+			engine:                 &v1.BuildEngine{Docker: &v1.DockerBuildEngine{}},
+			expectedPreferMinikube: true,
+			expectedLocalContexts:  []string{},
+		},
+		"configured docker engine": {
+			engine:                 &v1.BuildEngine{Docker: &v1.DockerBuildEngine{PreferMinikube: &preferMinikube, LocalContexts: &localContexts}},
+			expectedPreferMinikube: false,
+			expectedLocalContexts:  localContexts,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		preferMinikube, localContexts := getDockerEngineOptions(testCase.engine)
+		if preferMinikube != testCase.expectedPreferMinikube || reflect.DeepEqual(localContexts, testCase.expectedLocalContexts) == false {
+			t.Errorf("%s: expected %v and %v, got %v and %v", testName, testCase.expectedPreferMinikube, testCase.expectedLocalContexts, preferMinikube, localContexts)
+		}
+	}
+}
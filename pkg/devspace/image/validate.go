@@ -0,0 +1,73 @@
+package image
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/covexo/devspace/pkg/devspace/config/v1"
+)
+
+// IsBuildDisabled returns true if the image has no build section or the build is disabled. Such images are
+// not built by the DevSpace CLI (e.g. a pinned redis image) and are only deployed with their name and tag
+func IsBuildDisabled(imageConf *v1.ImageConfig) bool {
+	return imageConf.Build == nil || (imageConf.Build.Disabled != nil && *imageConf.Build.Disabled)
+}
+
+// ValidateImages checks that every image has a name, that images which are not built have a tag and
+// that the other images have a registry and an existing Dockerfile. The images are checked in the order of their names
+func ValidateImages(config *v1.Config, workdir string) error {
+	if config.Images == nil {
+		return nil
+	}
+
+	imageNames := make([]string, 0, len(*config.Images))
+	for imageName := range *config.Images {
+		imageNames = append(imageNames, imageName)
+	}
+
+	sort.Strings(imageNames)
+
+	for _, imageName := range imageNames {
+		err := validateImage(imageName, (*config.Images)[imageName], workdir)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func validateImage(imageName string, imageConf *v1.ImageConfig, workdir string) error {
+	if imageConf == nil || imageConf.Name == nil || *imageConf.Name == "" {
+		return fmt.Errorf("Invalid config: images.%s.name is missing", imageName)
+	}
+
+	if IsBuildDisabled(imageConf) {
+		hasDigest := imageConf.DeployByDigest != nil && *imageConf.DeployByDigest && imageConf.Digest != nil && *imageConf.Digest != ""
+		if (imageConf.Tag == nil || *imageConf.Tag == "") && hasDigest == false {
+			return fmt.Errorf("Invalid config: images.%s.tag is missing. Images that are not built by DevSpace are deployed with their configured tag", imageName)
+		}
+
+		return nil
+	}
+
+	if imageConf.Registry == nil || *imageConf.Registry == "" {
+		return fmt.Errorf("Invalid config: images.%s.registry is missing. Images that are built by DevSpace are pushed to this registry", imageName)
+	}
+
+	dockerfilePath := getBuildPaths(workdir, imageConf).dockerfilePath
+
+	_, err := os.Stat(dockerfilePath)
+	if err != nil {
+		relativePath, relErr := filepath.Rel(workdir, dockerfilePath)
+		if relErr != nil {
+			relativePath = dockerfilePath
+		}
+
+		return fmt.Errorf("Invalid config: Dockerfile %s of image '%s' not found. Fix images.%s.build.dockerfilePath or set images.%s.build.disabled to true if the image is not built by DevSpace", relativePath, imageName, imageName, imageName)
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package image
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/covexo/devspace/pkg/devspace/config/v1"
+)
+
+func TestValidateImages(t *testing.T) {
+	workdir, err := ioutil.TempDir("", "devspace-validate")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(workdir)
+
+	err = ioutil.WriteFile(filepath.Join(workdir, "Dockerfile"), []byte("FROM alpine"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	name := "app"
+	tag := "5.0"
+	registryName := "default"
+	disabled := true
+	missingDockerfile := "./api/Dockerfile"
+
+	testCases := map[string]struct {
+		imageConf     *v1.ImageConfig
+		expectedError string
+	}{
+		"built image": {
+			imageConf: &v1.ImageConfig{Name: &name, Registry: &registryName, Build: &v1.BuildConfig{}},
+		},
+		"built image without registry": {
+			imageConf:     &v1.ImageConfig{Name: &name, Build: &v1.BuildConfig{}},
+			expectedError: "images.test.registry is missing",
+		},
+		"image without build section": {
+			imageConf: &v1.ImageConfig{Name: &name, Tag: &tag},
+		},
+		"image with disabled build": {
+			imageConf: &v1.ImageConfig{Name: &name, Tag: &tag, Build: &v1.BuildConfig{Disabled: &disabled, DockerfilePath: &missingDockerfile}},
+		},
+		"image without name": {
+			imageConf:     &v1.ImageConfig{Tag: &tag},
+			expectedError: "images.test.name is missing",
+		},
+		"image without build section and tag": {
+			imageConf:     &v1.ImageConfig{Name: &name},
+			expectedError: "images.test.tag is missing",
+		},
+		"image with missing Dockerfile": {
+			imageConf:     &v1.ImageConfig{Name: &name, Registry: &registryName, Build: &v1.BuildConfig{DockerfilePath: &missingDockerfile}},
+			expectedError: "Dockerfile " + filepath.Join("api", "Dockerfile") + " of image 'test' not found",
+		},
+	}
+
+	for testName, testCase := range testCases {
+		err := ValidateImages(&v1.Config{
+			Images: &map[string]*v1.ImageConfig{
+				"test": testCase.imageConf,
+			},
+		}, workdir)
+
+		if testCase.expectedError == "" && err != nil {
+			t.Errorf("%s: unexpected error %v", testName, err)
+		} else if testCase.expectedError != "" && (err == nil || strings.Contains(err.Error(), testCase.expectedError) == false) {
+			t.Errorf("%s: expected error containing %q, got %v", testName, testCase.expectedError, err)
+		}
+	}
+}
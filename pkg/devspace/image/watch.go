@@ -0,0 +1,212 @@
+package image
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	glob "github.com/bmatcuk/doublestar"
+	"github.com/covexo/devspace/pkg/devspace/config/v1"
+	"github.com/rjeczalik/notify"
+)
+
+// watchDelay is the time without further changes the watcher waits for before it reports changed images,
+// so that e.g. a package manager writing several files only triggers a single rebuild
+const watchDelay = time.Second
+
+// Watcher watches the Dockerfiles and the rebuildOn paths of images and reports which images changed
+type Watcher struct {
+	// Patterns holds the watched paths per image name, relative to the workdir in slash notation
+	Patterns map[string][]string
+
+	workdir  string
+	onChange func(imageNames []string)
+
+	events   chan notify.EventInfo
+	stopChan chan bool
+	stopOnce sync.Once
+}
+
+// GetWatchPatterns returns the paths that trigger a rebuild for all images that enabled build.watch.
+// These are the Dockerfile and the rebuildOn globs, relative to the workdir in slash notation
+func GetWatchPatterns(config *v1.Config, workdir string) map[string][]string {
+	patterns := map[string][]string{}
+	if config.Images == nil {
+		return patterns
+	}
+
+	for imageName, imageConf := range *config.Images {
+		if IsBuildDisabled(imageConf) || imageConf.Build.Watch == nil || *imageConf.Build.Watch == false {
+			continue
+		}
+
+		dockerfilePath, err := filepath.Rel(workdir, getBuildPaths(workdir, imageConf).dockerfilePath)
+		if err != nil {
+			continue
+		}
+
+		imagePatterns := []string{filepath.ToSlash(dockerfilePath)}
+
+		if imageConf.Build.RebuildOn != nil {
+			for _, pattern := range *imageConf.Build.RebuildOn {
+				imagePatterns = append(imagePatterns, path.Clean(filepath.ToSlash(pattern)))
+			}
+		}
+
+		patterns[imageName] = imagePatterns
+	}
+
+	return patterns
+}
+
+// NewWatcher creates a watcher that calls onChange with the sorted names of the changed images.
+// onChange is called sequentially, changes that happen in the meantime are reported afterwards
+func NewWatcher(workdir string, patterns map[string][]string, onChange func(imageNames []string)) *Watcher {
+	return &Watcher{
+		Patterns: patterns,
+		workdir:  workdir,
+		onChange: onChange,
+		events:   make(chan notify.EventInfo, 1000),
+		stopChan: make(chan bool),
+	}
+}
+
+// Start watches the directories containing the patterns and reports changes in the background
+func (w *Watcher) Start() error {
+	for _, watchPath := range w.getWatchPaths() {
+		err := notify.Watch(watchPath, w.events, notify.All)
+		if err != nil {
+			notify.Stop(w.events)
+			return err
+		}
+	}
+
+	go w.run()
+
+	return nil
+}
+
+// Stop stops watching, a running onChange call is not interrupted
+func (w *Watcher) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.stopChan)
+		notify.Stop(w.events)
+	})
+}
+
+func (w *Watcher) run() {
+	changedImages := map[string]bool{}
+	var delay <-chan time.Time
+
+	for {
+		select {
+		case <-w.stopChan:
+			return
+		case event := <-w.events:
+			imageNames := w.GetChangedImages(event.Path())
+			if len(imageNames) == 0 {
+				continue
+			}
+
+			for _, imageName := range imageNames {
+				changedImages[imageName] = true
+			}
+
+			delay = time.After(watchDelay)
+		case <-delay:
+			imageNames := make([]string, 0, len(changedImages))
+			for imageName := range changedImages {
+				imageNames = append(imageNames, imageName)
+			}
+
+			sort.Strings(imageNames)
+
+			changedImages = map[string]bool{}
+			delay = nil
+
+			w.onChange(imageNames)
+		}
+	}
+}
+
+// GetChangedImages returns the sorted names of the images that have a pattern matching the given
+// absolute path. A pattern that matches a directory matches all paths within it
+func (w *Watcher) GetChangedImages(absolutePath string) []string {
+	relativePath, err := filepath.Rel(w.workdir, absolutePath)
+	if err != nil {
+		return nil
+	}
+
+	relativePath = filepath.ToSlash(relativePath)
+	imageNames := []string{}
+
+	for imageName, patterns := range w.Patterns {
+		for _, pattern := range patterns {
+			if matchesPattern(pattern, relativePath) {
+				imageNames = append(imageNames, imageName)
+				break
+			}
+		}
+	}
+
+	sort.Strings(imageNames)
+
+	return imageNames
+}
+
+// getWatchPaths returns the notify paths for the patterns. Static files are watched through their
+// directory, globs and directories through their static prefix including all subdirectories
+func (w *Watcher) getWatchPaths() []string {
+	watchPaths := []string{}
+	seen := map[string]bool{}
+
+	for _, patterns := range w.Patterns {
+		for _, pattern := range patterns {
+			prefix, isGlob := getStaticPrefix(pattern)
+			absolutePrefix := filepath.Join(w.workdir, filepath.FromSlash(prefix))
+
+			watchPath := filepath.Dir(absolutePrefix)
+			if isGlob {
+				watchPath = absolutePrefix + "/..."
+			} else if stat, err := os.Stat(absolutePrefix); err == nil && stat.IsDir() {
+				watchPath = absolutePrefix + "/..."
+			}
+
+			if seen[watchPath] == false {
+				seen[watchPath] = true
+				watchPaths = append(watchPaths, watchPath)
+			}
+		}
+	}
+
+	sort.Strings(watchPaths)
+
+	return watchPaths
+}
+
+// getStaticPrefix returns the path components of the pattern before the first glob and if the pattern contains a glob
+func getStaticPrefix(pattern string) (string, bool) {
+	components := strings.Split(pattern, "/")
+
+	for i, component := range components {
+		if strings.ContainsAny(component, "*?[{\\") {
+			return strings.Join(components[:i], "/"), true
+		}
+	}
+
+	return pattern, false
+}
+
+func matchesPattern(pattern, relativePath string) bool {
+	matches, _ := glob.Match(pattern, relativePath)
+	if matches {
+		return true
+	}
+
+	matches, _ = glob.Match(strings.TrimSuffix(pattern, "/")+"/**", relativePath)
+	return matches
+}
@@ -0,0 +1,154 @@
+package image
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/covexo/devspace/pkg/devspace/config/v1"
+)
+
+func TestGetWatchPatterns(t *testing.T) {
+	enabled := true
+	dockerfilePath := "./build/Dockerfile"
+	rebuildOn := []string{"package.json", "./deps/**/*.lock"}
+
+	config := &v1.Config{
+		Images: &map[string]*v1.ImageConfig{
+			"default": {
+				Build: &v1.BuildConfig{
+					Watch:     &enabled,
+					RebuildOn: &rebuildOn,
+				},
+			},
+			"api": {
+				Build: &v1.BuildConfig{
+					DockerfilePath: &dockerfilePath,
+					Watch:          &enabled,
+				},
+			},
+			"database": {
+				Build: &v1.BuildConfig{},
+			},
+		},
+	}
+
+	patterns := GetWatchPatterns(config, "/project")
+	expected := map[string][]string{
+		"default": {"Dockerfile", "package.json", "deps/**/*.lock"},
+		"api":     {"build/Dockerfile"},
+	}
+
+	if reflect.DeepEqual(patterns, expected) == false {
+		t.Fatalf("Expected patterns %v, got %v", expected, patterns)
+	}
+}
+
+func TestGetChangedImages(t *testing.T) {
+	workdir := filepath.FromSlash("/project")
+	watcher := NewWatcher(workdir, map[string][]string{
+		"default": {"Dockerfile", "package.json", "deps"},
+		"api":     {"api/Dockerfile", "api/**/*.mod", "package.json"},
+	}, nil)
+
+	testCases := map[string][]string{
+		"Dockerfile":             {"default"},
+		"package.json":           {"api", "default"},
+		"deps/lib/index.js":      {"default"},
+		"api/Dockerfile":         {"api"},
+		"api/go.mod":             {"api"},
+		"api/module/sub/go.mod":  {"api"},
+		"api/main.go":            {},
+		"src/package.json":       {},
+		"../project2/Dockerfile": {},
+	}
+
+	for path, expected := range testCases {
+		imageNames := watcher.GetChangedImages(filepath.Join(workdir, filepath.FromSlash(path)))
+		if reflect.DeepEqual(imageNames, expected) == false {
+			t.Errorf("Expected %v for %s, got %v", expected, path, imageNames)
+		}
+	}
+}
+
+func TestGetStaticPrefix(t *testing.T) {
+	testCases := map[string]struct {
+		prefix string
+		isGlob bool
+	}{
+		"Dockerfile":          {"Dockerfile", false},
+		"api/Dockerfile":      {"api/Dockerfile", false},
+		"**/package.json":     {"", true},
+		"deps/**/*.lock":      {"deps", true},
+		"api/v[12]/go.mod":    {"api", true},
+		"src/{a,b}/index.js":  {"src", true},
+		"src/module/*.gradle": {"src/module", true},
+	}
+
+	for pattern, expected := range testCases {
+		prefix, isGlob := getStaticPrefix(pattern)
+		if prefix != expected.prefix || isGlob != expected.isGlob {
+			t.Errorf("Expected %s, %v for %s, got %s, %v", expected.prefix, expected.isGlob, pattern, prefix, isGlob)
+		}
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	workdir, err := ioutil.TempDir("", "devspace-watch")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(workdir)
+
+	// Events are reported for the resolved path, e.g. /private/var instead of /var on macOS
+	workdir, err = filepath.EvalSymlinks(workdir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.MkdirAll(filepath.Join(workdir, "deps"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changes := make(chan []string, 10)
+	watcher := NewWatcher(workdir, map[string][]string{
+		"default": {"Dockerfile", "deps/**/*.lock"},
+	}, func(imageNames []string) {
+		changes <- imageNames
+	})
+
+	err = watcher.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer watcher.Stop()
+
+	// Unrelated files don't trigger a rebuild, several changes trigger a single rebuild
+	for _, path := range []string{"index.js", "Dockerfile", "deps/yarn.lock"} {
+		err = ioutil.WriteFile(filepath.Join(workdir, path), []byte("changed"), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	select {
+	case imageNames := <-changes:
+		if reflect.DeepEqual(imageNames, []string{"default"}) == false {
+			t.Fatalf("Expected [default], got %v", imageNames)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Timeout waiting for the change")
+	}
+
+	select {
+	case imageNames := <-changes:
+		t.Fatalf("Expected a single change, got another one for %v", imageNames)
+	case <-time.After(2 * watchDelay):
+	}
+}
@@ -0,0 +1,146 @@
+package portforward
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// HealthCheckTCP checks if a connection to the port is accepted by the pod
+	HealthCheckTCP = "tcp"
+
+	// HealthCheckHTTP sends a GET request and expects a status code between 200 and 399
+	HealthCheckHTTP = "http"
+)
+
+// DefaultHealthCheckInterval is used if no interval is configured for a health check
+const DefaultHealthCheckInterval = 10 * time.Second
+
+var healthCheckTimeout = 5 * time.Second
+
+// HealthCheck probes a forwarded local port
+type HealthCheck struct {
+	Type     string
+	Path     string
+	Interval time.Duration
+}
+
+// Validate checks if the health check is configured correctly
+func (h *HealthCheck) Validate() error {
+	if h.Type != HealthCheckTCP && h.Type != HealthCheckHTTP {
+		return fmt.Errorf("Unsupported health check type %s (supported are %s and %s)", h.Type, HealthCheckTCP, HealthCheckHTTP)
+	}
+
+	if h.Interval < 0 {
+		return errors.New("Health check interval must not be negative")
+	}
+
+	return nil
+}
+
+// probe runs the health check against the local port, so the complete way to the pod is checked
+func (h *HealthCheck) probe(port *Port) error {
+	address := net.JoinHostPort(getProbeAddress(port.BindAddress), strconv.Itoa(port.LocalPort))
+
+	if h.Type == HealthCheckHTTP {
+		return probeHTTP("http://"+address+h.Path, healthCheckTimeout)
+	}
+
+	return probeTCP(address, healthCheckTimeout)
+}
+
+// probeTCP connects to the address. Our local listener always accepts the connection,
+// but it is closed right away if the connection within the pod is refused
+func probeTCP(address string, timeout time.Duration) error {
+	conn, err := net.DialTimeout("tcp", address, timeout)
+	if err != nil {
+		return err
+	}
+
+	defer conn.Close()
+
+	conn.SetReadDeadline(time.Now().Add(500 * time.Millisecond))
+
+	_, err = conn.Read(make([]byte, 1))
+	if err == io.EOF {
+		return errors.New("Connection refused within the pod")
+	}
+
+	// A timeout means the connection is open and the server waits for the client
+	return nil
+}
+
+func probeHTTP(url string, timeout time.Duration) error {
+	client := &http.Client{
+		Timeout: timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return fmt.Errorf("Health check %s returned status code %d", url, resp.StatusCode)
+	}
+
+	return nil
+}
+
+// getProbeAddress returns the address the port can be reached on locally
+func getProbeAddress(bindAddress string) string {
+	ip := net.ParseIP(bindAddress)
+	if ip != nil && ip.IsUnspecified() {
+		if ip.To4() != nil {
+			return "127.0.0.1"
+		}
+
+		return "::1"
+	}
+
+	return bindAddress
+}
+
+// runHealthCheck probes the port until the port forwarding is stopped. Ports are only probed
+// while connected to the pod, reconnecting ports keep their state
+func (p *PortForwarding) runHealthCheck(port *Port) {
+	interval := port.HealthCheck.Interval
+	if interval == 0 {
+		interval = DefaultHealthCheckInterval
+	}
+
+	for {
+		select {
+		case <-p.stopChan:
+			return
+		case <-time.After(interval):
+		}
+
+		err := port.HealthCheck.probe(port)
+
+		port.stateMutex.Lock()
+		connected := port.state == StateReady || port.state == StateUnhealthy
+		port.stateMutex.Unlock()
+
+		// The state could have changed while probing
+		if connected == false {
+			continue
+		}
+
+		if err != nil {
+			p.setState([]*Port{port}, StateUnhealthy, err)
+		} else {
+			p.setState([]*Port{port}, StateReady, nil)
+		}
+	}
+}
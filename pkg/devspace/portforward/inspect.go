@@ -0,0 +1,209 @@
+package portforward
+
+import (
+	"bufio"
+	"errors"
+	"io/ioutil"
+	stdlog "log"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/covexo/devspace/pkg/util/log"
+)
+
+var inspectLog log.Logger
+
+// Request holds the metadata of a request to an inspected port
+type Request struct {
+	Method  string
+	Path    string
+	Status  int
+	Latency time.Duration
+	Size    int64
+
+	// Hijacked is true if the connection was taken over, e.g. by a websocket. Latency and size then
+	// cover the whole connection
+	Hijacked bool
+}
+
+// String returns a summary line of the request
+func (r *Request) String() string {
+	summary := r.Method + " " + r.Path + " " + strconv.Itoa(r.Status) + " " + r.Latency.String() + " " + strconv.FormatInt(r.Size, 10) + "B"
+	if r.Hijacked {
+		summary += " (hijacked)"
+	}
+
+	return summary
+}
+
+// serveInspect serves the local port with a reverse proxy that records every request
+func (p *PortForwarding) serveInspect(port *Port) {
+	if inspectLog == nil {
+		inspectLog = log.GetFileLogger("http")
+	}
+
+	proxy := httputil.NewSingleHostReverseProxy(&url.URL{
+		Scheme: "http",
+		Host:   net.JoinHostPort(DefaultBindAddress, strconv.Itoa(port.internalPort)),
+	})
+
+	// Failed requests are recorded with status 502, so there is no need to print them again
+	proxy.ErrorLog = stdlog.New(ioutil.Discard, "", 0)
+
+	server := &http.Server{
+		Handler: p.inspectHandler(port, proxy),
+	}
+
+	err := server.Serve(port.listener)
+	if err != nil {
+		select {
+		case <-p.stopChan:
+		default:
+			p.setState([]*Port{port}, StateError, err)
+		}
+	}
+}
+
+func (p *PortForwarding) inspectHandler(port *Port, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorder := &responseRecorder{
+			ResponseWriter: w,
+			status:         http.StatusOK,
+		}
+
+		start := time.Now()
+		handler.ServeHTTP(recorder, r)
+
+		request := &Request{
+			Method:   r.Method,
+			Path:     r.URL.RequestURI(),
+			Status:   recorder.status,
+			Latency:  time.Since(start),
+			Size:     atomic.LoadInt64(&recorder.size),
+			Hijacked: recorder.hijacked,
+		}
+
+		if r.ContentLength > 0 {
+			atomic.AddInt64(&port.bytesSent, r.ContentLength)
+		}
+		atomic.AddInt64(&port.bytesSent, atomic.LoadInt64(&recorder.hijackedRead))
+		atomic.AddInt64(&port.bytesReceived, request.Size)
+
+		p.logRequest(port, request)
+	})
+}
+
+func (p *PortForwarding) logRequest(port *Port, request *Request) {
+	inspectLog.WithKey("name", p.Name).
+		WithKey("port", port.String()).
+		WithKey("method", request.Method).
+		WithKey("path", request.Path).
+		WithKey("status", request.Status).
+		WithKey("latency", request.Latency.Seconds()*1000).
+		WithKey("size", request.Size).
+		WithKey("hijacked", request.Hijacked).
+		Info(request.String())
+
+	if p.PrintRequests {
+		log.Infof("[%s] %s", port.String(), request.String())
+	}
+}
+
+// responseRecorder records the status code and the size of a response
+type responseRecorder struct {
+	// Sizes are written by the connection after a hijack, accessed atomically and therefore first in the struct
+	size         int64
+	hijackedRead int64
+
+	http.ResponseWriter
+
+	status      int
+	wroteHeader bool
+	hijacked    bool
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if r.wroteHeader == false {
+		r.status = status
+		r.wroteHeader = true
+	}
+
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	r.wroteHeader = true
+
+	n, err := r.ResponseWriter.Write(data)
+	atomic.AddInt64(&r.size, int64(n))
+
+	return n, err
+}
+
+// Flush passes flushes through, so that streamed responses are not buffered
+func (r *responseRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack passes hijacks through, so that websockets and other upgraded connections work. The reverse
+// proxy only hijacks the connection to switch protocols, so the exchange is recorded with status 101
+func (r *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := r.ResponseWriter.(http.Hijacker)
+	if ok == false {
+		return nil, nil, errors.New("Response writer does not support hijacking")
+	}
+
+	conn, readWriter, err := hijacker.Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r.hijacked = true
+	if r.wroteHeader == false {
+		r.status = http.StatusSwitchingProtocols
+		r.wroteHeader = true
+	}
+
+	countingConn := &hijackedConn{
+		Conn:     conn,
+		recorder: r,
+	}
+
+	// The buffered writer has to write through the counting connection as well
+	err = readWriter.Writer.Flush()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	readWriter.Writer.Reset(countingConn)
+
+	return countingConn, readWriter, nil
+}
+
+// hijackedConn counts the bytes transferred over a hijacked connection
+type hijackedConn struct {
+	net.Conn
+
+	recorder *responseRecorder
+}
+
+func (h *hijackedConn) Read(data []byte) (int, error) {
+	n, err := h.Conn.Read(data)
+	atomic.AddInt64(&h.recorder.hijackedRead, int64(n))
+
+	return n, err
+}
+
+func (h *hijackedConn) Write(data []byte) (int, error) {
+	n, err := h.Conn.Write(data)
+	atomic.AddInt64(&h.recorder.size, int64(n))
+
+	return n, err
+}
@@ -0,0 +1,438 @@
+package portforward
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/covexo/devspace/pkg/devspace/clients/kubectl"
+	"github.com/covexo/devspace/pkg/util/log"
+	k8sv1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// DefaultBindAddress is used if no bind address is configured for a port
+const DefaultBindAddress = "127.0.0.1"
+
+var forwardLog log.Logger
+
+// Port is a single forwarded port
+type Port struct {
+	// Transferred bytes, accessed atomically and therefore first in the struct
+	bytesSent     int64
+	bytesReceived int64
+
+	// BindAddress is the local address the port is opened on
+	BindAddress string
+
+	// LocalPort is the local port, 0 picks a free port that is set when the forwarding is started
+	LocalPort  int
+	RemotePort int
+
+	// HealthCheck optionally probes the local port
+	HealthCheck *HealthCheck
+
+	// Inspect serves the local port with a reverse proxy that records all http requests
+	Inspect bool
+
+	// Port on localhost the kubernetes port forwarding listens on
+	internalPort int
+	listener     net.Listener
+	reverse      bool
+
+	stateMutex sync.Mutex
+	state      State
+	err        string
+}
+
+// String returns the port in the local:remote format
+func (p *Port) String() string {
+	local := strconv.Itoa(p.LocalPort)
+	if p.BindAddress != DefaultBindAddress {
+		local = net.JoinHostPort(p.BindAddress, local)
+	}
+
+	return local + ":" + strconv.Itoa(p.RemotePort)
+}
+
+// PortForwarding forwards local ports to a pod and exposes local ports within the pod
+type PortForwarding struct {
+	Name      string
+	Kubectl   *kubernetes.Clientset
+	Pod       *k8sv1.Pod
+	Container string
+
+	Ports        []*Port
+	ReversePorts []*Port
+
+	// ResolvePod is called before reconnecting to find the current pod, if the pod was replaced
+	ResolvePod func() (*k8sv1.Pod, error)
+
+	// PrintRequests prints a summary line for every request to an inspected port
+	PrintRequests bool
+
+	podMutex sync.Mutex
+	stopChan chan struct{}
+	stopOnce sync.Once
+}
+
+// Start opens all local ports and starts the forwarding. If a local port can't be opened
+// nothing is forwarded and the reason is returned
+func (p *PortForwarding) Start() error {
+	if forwardLog == nil {
+		forwardLog = log.GetFileLogger("portforwarding")
+	}
+
+	p.stopChan = make(chan struct{})
+
+	for _, port := range p.ReversePorts {
+		port.reverse = true
+	}
+
+	for _, port := range p.Ports {
+		if port.HealthCheck != nil {
+			err := port.HealthCheck.Validate()
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(p.Ports) > 0 {
+		err := p.startForwarding()
+		if err != nil {
+			p.setState(p.Ports, StateError, err)
+			p.Stop()
+			return err
+		}
+	}
+
+	if len(p.ReversePorts) > 0 {
+		p.setState(p.ReversePorts, StateStarting, nil)
+
+		err := p.startReverseForwarding()
+		if err != nil {
+			p.setState(p.ReversePorts, StateError, err)
+			p.Stop()
+			return err
+		}
+
+		p.setState(p.ReversePorts, StateReady, nil)
+	}
+
+	go p.logStats()
+
+	return nil
+}
+
+// Stop stops the forwarding and closes all local ports
+func (p *PortForwarding) Stop() {
+	p.stopOnce.Do(func() {
+		close(p.stopChan)
+
+		for _, port := range p.Ports {
+			if port.listener != nil {
+				port.listener.Close()
+			}
+		}
+
+		// Failed ports keep their error
+		for _, port := range append(p.Ports, p.ReversePorts...) {
+			if port.Status().State != StateError {
+				p.setState([]*Port{port}, StateStopped, nil)
+			}
+		}
+	})
+}
+
+func (p *PortForwarding) getPod() *k8sv1.Pod {
+	p.podMutex.Lock()
+	defer p.podMutex.Unlock()
+
+	return p.Pod
+}
+
+func (p *PortForwarding) setPod(pod *k8sv1.Pod) {
+	p.podMutex.Lock()
+	defer p.podMutex.Unlock()
+
+	p.Pod = pod
+}
+
+func (p *PortForwarding) startForwarding() error {
+	// We open the local ports ourselves first, so that a port that is already in use is detected
+	// before anything is forwarded and the port can't be taken while we are connecting
+	for _, port := range p.Ports {
+		err := port.listen()
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, port := range p.Ports {
+		internalPort, err := GetFreePort(DefaultBindAddress)
+		if err != nil {
+			return err
+		}
+
+		port.internalPort = internalPort
+	}
+
+	p.setState(p.Ports, StateStarting, nil)
+
+	connectionStopChan, errorChan, err := p.connect()
+	if err != nil {
+		return err
+	}
+
+	p.setState(p.Ports, StateReady, nil)
+
+	for _, port := range p.Ports {
+		if port.Inspect {
+			go p.serveInspect(port)
+		} else {
+			go p.serve(port)
+		}
+
+		if port.HealthCheck != nil {
+			go p.runHealthCheck(port)
+		}
+	}
+
+	go p.monitor(connectionStopChan, errorChan)
+
+	return nil
+}
+
+// connect starts the kubernetes port forwarding to the internal ports and waits until it is ready.
+// The returned channel stops the connection, the error channel receives a value when the connection ended
+func (p *PortForwarding) connect() (chan struct{}, chan error, error) {
+	pod := p.getPod()
+	internalPorts := make([]string, 0, len(p.Ports))
+
+	for _, port := range p.Ports {
+		internalPorts = append(internalPorts, strconv.Itoa(port.internalPort)+":"+strconv.Itoa(port.RemotePort))
+	}
+
+	stopChan := make(chan struct{})
+	readyChan := make(chan struct{})
+	errorChan := make(chan error, 1)
+
+	go func() {
+		errorChan <- kubectl.ForwardPorts(p.Kubectl, pod, internalPorts, stopChan, readyChan)
+	}()
+
+	select {
+	case <-readyChan:
+	case err := <-errorChan:
+		if err == nil {
+			err = errors.New("Port forwarding stopped unexpectedly")
+		}
+
+		return nil, nil, fmt.Errorf("Unable to forward ports to pod %s: %v", pod.Name, err)
+	case <-time.After(10 * time.Second):
+		close(stopChan)
+		return nil, nil, fmt.Errorf("Timeout connecting to pod %s, check `devspace status` and .devspace/logs/portforwarding.log", pod.Name)
+	}
+
+	return stopChan, errorChan, nil
+}
+
+// monitor reconnects to the pod if the connection is lost until the port forwarding is stopped
+func (p *PortForwarding) monitor(connectionStopChan chan struct{}, errorChan chan error) {
+	for {
+		select {
+		case <-p.stopChan:
+			close(connectionStopChan)
+			return
+		case err := <-errorChan:
+			if err == nil {
+				err = errors.New("Lost connection to pod")
+			}
+
+			p.setState(p.Ports, StateReconnecting, err)
+
+			connectionStopChan, errorChan = p.reconnect()
+			if connectionStopChan == nil {
+				return
+			}
+
+			p.setState(p.Ports, StateReady, nil)
+		}
+	}
+}
+
+// reconnect tries to connect to the pod with an increasing delay. It returns nil
+// if the port forwarding was stopped in the meantime
+func (p *PortForwarding) reconnect() (chan struct{}, chan error) {
+	delay := time.Second
+
+	for {
+		select {
+		case <-p.stopChan:
+			return nil, nil
+		case <-time.After(delay):
+		}
+
+		if delay < 30*time.Second {
+			delay *= 2
+		}
+
+		// The pod could have been replaced, e.g. after a redeploy
+		if p.ResolvePod != nil {
+			pod, err := p.ResolvePod()
+			if err != nil {
+				p.setState(p.Ports, StateReconnecting, err)
+				continue
+			} else if pod == nil {
+				p.setState(p.Ports, StateReconnecting, errors.New("No running pod found"))
+				continue
+			}
+
+			p.setPod(pod)
+		}
+
+		connectionStopChan, errorChan, err := p.connect()
+		if err != nil {
+			p.setState(p.Ports, StateReconnecting, err)
+			continue
+		}
+
+		return connectionStopChan, errorChan
+	}
+}
+
+func (p *PortForwarding) startReverseForwarding() error {
+	pod := p.getPod()
+	reversePorts := make([]string, 0, len(p.ReversePorts))
+	for _, port := range p.ReversePorts {
+		reversePorts = append(reversePorts, strconv.Itoa(port.LocalPort)+":"+strconv.Itoa(port.RemotePort))
+	}
+
+	readyChan := make(chan struct{})
+	errorChan := make(chan error, 1)
+
+	go func() {
+		errorChan <- kubectl.ReverseForwardPorts(p.Kubectl, pod, p.Container, reversePorts, p.stopChan, readyChan)
+	}()
+
+	select {
+	case <-readyChan:
+	case err := <-errorChan:
+		return err
+	case <-time.After(15 * time.Second):
+		return fmt.Errorf("Timeout starting reverse port forwarding in pod %s", pod.Name)
+	}
+
+	return nil
+}
+
+func (p *PortForwarding) serve(port *Port) {
+	for {
+		conn, err := port.listener.Accept()
+		if err != nil {
+			select {
+			case <-p.stopChan:
+			default:
+				p.setState([]*Port{port}, StateError, fmt.Errorf("Error accepting connections: %v", err))
+			}
+
+			return
+		}
+
+		go p.handleConnection(port, conn)
+	}
+}
+
+func (p *PortForwarding) handleConnection(port *Port, conn net.Conn) {
+	defer conn.Close()
+
+	internalConn, err := net.Dial("tcp", net.JoinHostPort(DefaultBindAddress, strconv.Itoa(port.internalPort)))
+	if err != nil {
+		forwardLog.WithKey("name", p.Name).Errorf("Error forwarding connection on %s: %v", port.String(), err)
+		return
+	}
+
+	defer internalConn.Close()
+
+	copyDone := make(chan struct{}, 2)
+
+	go func() {
+		io.Copy(&countingWriter{counter: &port.bytesSent, writer: internalConn}, conn)
+		copyDone <- struct{}{}
+	}()
+
+	go func() {
+		io.Copy(&countingWriter{counter: &port.bytesReceived, writer: conn}, internalConn)
+		copyDone <- struct{}{}
+	}()
+
+	<-copyDone
+}
+
+func (p *Port) listen() error {
+	if p.BindAddress == "" {
+		p.BindAddress = DefaultBindAddress
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(p.BindAddress, strconv.Itoa(p.LocalPort)))
+	if err != nil {
+		return getListenError(p, err)
+	}
+
+	p.listener = listener
+
+	// Remember the picked port if the port was chosen automatically
+	if p.LocalPort == 0 {
+		p.LocalPort = listener.Addr().(*net.TCPAddr).Port
+	}
+
+	return nil
+}
+
+// GetFreePort returns a local port that is currently not in use
+func GetFreePort(bindAddress string) (int, error) {
+	listener, err := net.Listen("tcp", net.JoinHostPort(bindAddress, "0"))
+	if err != nil {
+		return 0, err
+	}
+
+	defer listener.Close()
+
+	return listener.Addr().(*net.TCPAddr).Port, nil
+}
+
+func getListenError(port *Port, err error) error {
+	address := net.JoinHostPort(port.BindAddress, strconv.Itoa(port.LocalPort))
+
+	if isErrno(err, syscall.EADDRINUSE) || strings.Contains(err.Error(), "address already in use") || strings.Contains(err.Error(), "Only one usage of each socket address") {
+		return fmt.Errorf("Local port %s is already in use by another process (use another localPort or localPort: auto)", address)
+	}
+
+	if isErrno(err, syscall.EACCES) {
+		return fmt.Errorf("Permission denied to open local port %s (ports below 1024 usually require root privileges)", address)
+	}
+
+	if isErrno(err, syscall.EADDRNOTAVAIL) || strings.Contains(err.Error(), "assign requested address") {
+		return fmt.Errorf("Bind address %s is not an address of this machine", port.BindAddress)
+	}
+
+	return fmt.Errorf("Unable to open local port %s: %v", address, err)
+}
+
+func isErrno(err error, errno syscall.Errno) bool {
+	if opErr, ok := err.(*net.OpError); ok {
+		if syscallErr, ok := opErr.Err.(*os.SyscallError); ok {
+			return syscallErr.Err == errno
+		}
+	}
+
+	return false
+}
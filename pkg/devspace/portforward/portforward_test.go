@@ -0,0 +1,433 @@
+package portforward
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/covexo/devspace/pkg/util/log"
+)
+
+func TestMain(m *testing.M) {
+	logDir, err := ioutil.TempDir("", "devspace-portforward")
+	if err != nil {
+		panic(err)
+	}
+
+	log.Logdir = logDir + "/"
+	code := m.Run()
+
+	os.RemoveAll(logDir)
+	os.Exit(code)
+}
+
+func TestListenAutoPort(t *testing.T) {
+	port := &Port{
+		RemotePort: 80,
+	}
+
+	err := port.listen()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer port.listener.Close()
+
+	if port.LocalPort == 0 {
+		t.Fatal("Expected a local port to be picked")
+	}
+
+	if port.BindAddress != DefaultBindAddress {
+		t.Fatalf("Expected bind address %s, got %s", DefaultBindAddress, port.BindAddress)
+	}
+
+	if port.String() != strconv.Itoa(port.LocalPort)+":80" {
+		t.Fatalf("Unexpected port string %s", port.String())
+	}
+}
+
+func TestListenPortInUse(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer listener.Close()
+
+	port := &Port{
+		LocalPort:  listener.Addr().(*net.TCPAddr).Port,
+		RemotePort: 80,
+	}
+
+	err = port.listen()
+	if err == nil {
+		port.listener.Close()
+		t.Fatal("Expected an error for a port that is already in use")
+	}
+
+	if strings.Contains(err.Error(), "already in use") == false {
+		t.Fatalf("Expected an already in use error, got: %v", err)
+	}
+}
+
+func TestListenBindAddress(t *testing.T) {
+	freePort, err := GetFreePort("127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	port := &Port{
+		BindAddress: "0.0.0.0",
+		LocalPort:   freePort,
+		RemotePort:  8080,
+	}
+
+	err = port.listen()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer port.listener.Close()
+
+	if port.String() != "0.0.0.0:"+strconv.Itoa(freePort)+":8080" {
+		t.Fatalf("Unexpected port string %s", port.String())
+	}
+}
+
+func TestHandleConnectionCountsBytes(t *testing.T) {
+	// The echo server takes the place of the kubernetes port forwarding
+	echoListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer echoListener.Close()
+
+	go func() {
+		conn, err := echoListener.Accept()
+		if err != nil {
+			return
+		}
+
+		defer conn.Close()
+		io.Copy(conn, conn)
+	}()
+
+	port := &Port{
+		RemotePort:   80,
+		internalPort: echoListener.Addr().(*net.TCPAddr).Port,
+	}
+
+	local, remote := net.Pipe()
+	portForwarding := &PortForwarding{}
+
+	done := make(chan struct{})
+
+	go func() {
+		portForwarding.handleConnection(port, remote)
+		close(done)
+	}()
+
+	_, err = local.Write([]byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response := make([]byte, 5)
+	_, err = io.ReadFull(local, response)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	local.Close()
+	<-done
+
+	// The response is counted after it was written, so it can take a moment
+	status := port.Status()
+	for i := 0; i < 100 && status.BytesReceived != 5; i++ {
+		time.Sleep(10 * time.Millisecond)
+		status = port.Status()
+	}
+
+	if status.BytesSent != 5 || status.BytesReceived != 5 {
+		t.Fatalf("Expected 5 bytes sent and received, got %d and %d", status.BytesSent, status.BytesReceived)
+	}
+}
+
+func TestSetState(t *testing.T) {
+	port := &Port{}
+
+	if port.setState(StateReady, nil) == false {
+		t.Fatal("Expected the state to change")
+	}
+	if port.setState(StateReady, nil) {
+		t.Fatal("Expected the state to be unchanged")
+	}
+	if port.setState(StateReconnecting, errors.New("Lost connection to pod")) == false {
+		t.Fatal("Expected the state to change")
+	}
+	if port.setState(StateReconnecting, errors.New("No running pod found")) == false {
+		t.Fatal("Expected a changed error to be a change")
+	}
+
+	status := port.Status()
+	if status.State != StateReconnecting || status.Error != "No running pod found" {
+		t.Fatalf("Unexpected status %#v", status)
+	}
+}
+
+func TestProbeHTTP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/healthz" {
+			w.WriteHeader(http.StatusOK)
+		} else {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+
+	defer server.Close()
+
+	err := probeHTTP(server.URL+"/healthz", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = probeHTTP(server.URL+"/broken", time.Second)
+	if err == nil {
+		t.Fatal("Expected an error for status code 500")
+	}
+}
+
+func TestProbeTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer listener.Close()
+
+	closeConnections := make(chan bool, 1)
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			// Closing the connection right away is what happens if the connection within the pod is refused
+			if <-closeConnections {
+				conn.Close()
+			} else {
+				defer conn.Close()
+			}
+		}
+	}()
+
+	closeConnections <- false
+
+	err = probeTCP(listener.Addr().String(), time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	closeConnections <- true
+
+	err = probeTCP(listener.Addr().String(), time.Second)
+	if err == nil {
+		t.Fatal("Expected an error for a closed connection")
+	}
+}
+
+func TestGetProbeAddress(t *testing.T) {
+	if address := getProbeAddress("0.0.0.0"); address != "127.0.0.1" {
+		t.Fatalf("Expected 127.0.0.1, got %s", address)
+	}
+	if address := getProbeAddress("::"); address != "::1" {
+		t.Fatalf("Expected ::1, got %s", address)
+	}
+	if address := getProbeAddress("192.168.0.10"); address != "192.168.0.10" {
+		t.Fatalf("Expected 192.168.0.10, got %s", address)
+	}
+}
+
+func TestInspect(t *testing.T) {
+	// The backend takes the place of the kubernetes port forwarding
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("created"))
+	}))
+
+	defer backend.Close()
+
+	port := &Port{
+		RemotePort:   80,
+		Inspect:      true,
+		internalPort: backend.Listener.Addr().(*net.TCPAddr).Port,
+	}
+
+	err := port.listen()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	portForwarding := &PortForwarding{
+		Name:     "api",
+		stopChan: make(chan struct{}),
+	}
+
+	go portForwarding.serveInspect(port)
+	defer portForwarding.Stop()
+
+	resp, err := http.Post("http://127.0.0.1:"+strconv.Itoa(port.LocalPort)+"/users?page=2", "text/plain", strings.NewReader("body"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("Expected status code 201, got %d", resp.StatusCode)
+	}
+
+	// The request is logged after the response was sent
+	var data []byte
+	for i := 0; i < 100 && len(data) == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+
+		logFile, _ := ioutil.ReadFile(filepath.Join(log.Logdir, "http.log"))
+		lines := strings.Split(strings.TrimSpace(string(logFile)), "\n")
+		if len(lines) > 0 && strings.Contains(lines[len(lines)-1], "/users?page=2") {
+			data = []byte(lines[len(lines)-1])
+		}
+	}
+
+	entry := make(map[string]interface{})
+	err = json.Unmarshal(data, &entry)
+	if err != nil {
+		t.Fatalf("Error parsing %s: %v", string(data), err)
+	}
+
+	if entry["method"] != "POST" || entry["path"] != "/users?page=2" || entry["status"] != float64(201) || entry["size"] != float64(7) || entry["name"] != "api" {
+		t.Fatalf("Unexpected log entry %s", string(data))
+	}
+
+	status := port.Status()
+	if status.BytesSent != 4 || status.BytesReceived != 7 {
+		t.Fatalf("Expected 4 bytes sent and 7 received, got %d and %d", status.BytesSent, status.BytesReceived)
+	}
+}
+
+func TestInspectUpgrade(t *testing.T) {
+	// The backend switches to a protocol that echoes every line
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, readWriter, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return
+		}
+
+		defer conn.Close()
+
+		readWriter.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: echo\r\n\r\n")
+		readWriter.Flush()
+
+		line, _ := readWriter.ReadString('\n')
+		readWriter.WriteString(line)
+		readWriter.Flush()
+	}))
+
+	defer backend.Close()
+
+	port := &Port{
+		RemotePort:   80,
+		Inspect:      true,
+		internalPort: backend.Listener.Addr().(*net.TCPAddr).Port,
+	}
+
+	err := port.listen()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	portForwarding := &PortForwarding{
+		Name:     "api",
+		stopChan: make(chan struct{}),
+	}
+
+	go portForwarding.serveInspect(port)
+	defer portForwarding.Stop()
+
+	conn, err := net.Dial("tcp", "127.0.0.1:"+strconv.Itoa(port.LocalPort))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer conn.Close()
+
+	request, _ := http.NewRequest("GET", "http://127.0.0.1/socket", nil)
+	request.Header.Set("Connection", "Upgrade")
+	request.Header.Set("Upgrade", "echo")
+
+	err = request.Write(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reader := bufio.NewReader(conn)
+
+	resp, err := http.ReadResponse(reader, request)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("Expected status code 101, got %d", resp.StatusCode)
+	}
+
+	conn.Write([]byte("ping\n"))
+
+	line, err := reader.ReadString('\n')
+	if err != nil || line != "ping\n" {
+		t.Fatalf("Expected echoed line, got %q (%v)", line, err)
+	}
+
+	conn.Close()
+
+	// The exchange is logged after the connection was closed
+	var data []byte
+	for i := 0; i < 100 && len(data) == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+
+		logFile, _ := ioutil.ReadFile(filepath.Join(log.Logdir, "http.log"))
+		lines := strings.Split(strings.TrimSpace(string(logFile)), "\n")
+		if len(lines) > 0 && strings.Contains(lines[len(lines)-1], "/socket") {
+			data = []byte(lines[len(lines)-1])
+		}
+	}
+
+	entry := make(map[string]interface{})
+	err = json.Unmarshal(data, &entry)
+	if err != nil {
+		t.Fatalf("Error parsing %s: %v", string(data), err)
+	}
+
+	if entry["status"] != float64(101) || entry["hijacked"] != true {
+		t.Fatalf("Unexpected log entry %s", string(data))
+	}
+
+	status := port.Status()
+	if status.BytesSent != 5 || status.BytesReceived == 0 {
+		t.Fatalf("Expected 5 bytes sent and the response received, got %d and %d", status.BytesSent, status.BytesReceived)
+	}
+}
@@ -0,0 +1,153 @@
+package portforward
+
+import (
+	"io"
+	"sync/atomic"
+	"time"
+
+	"github.com/covexo/devspace/pkg/util/log"
+)
+
+// State describes the state of a forwarded port
+type State string
+
+const (
+	// StateStarting means the port forwarding is connecting to the pod
+	StateStarting State = "Starting"
+
+	// StateReady means connections to the local port are forwarded
+	StateReady State = "Ready"
+
+	// StateUnhealthy means the connection to the pod is established, but the health check fails
+	StateUnhealthy State = "Unhealthy"
+
+	// StateReconnecting means the connection to the pod was lost and is reestablished
+	StateReconnecting State = "Reconnecting"
+
+	// StateError means the port forwarding failed and is not retried
+	StateError State = "Error"
+
+	// StateStopped means the port forwarding was stopped
+	StateStopped State = "Stopped"
+)
+
+// statsInterval is the interval the transferred bytes are written to the log
+var statsInterval = 10 * time.Second
+
+// Status is a snapshot of the state of a forwarded port
+type Status struct {
+	State State
+	Error string
+
+	// BytesSent are the bytes sent from the local port to the pod, BytesReceived the other way round
+	BytesSent     int64
+	BytesReceived int64
+}
+
+// Status returns the current status of the port
+func (p *Port) Status() Status {
+	p.stateMutex.Lock()
+	defer p.stateMutex.Unlock()
+
+	return Status{
+		State:         p.state,
+		Error:         p.err,
+		BytesSent:     atomic.LoadInt64(&p.bytesSent),
+		BytesReceived: atomic.LoadInt64(&p.bytesReceived),
+	}
+}
+
+// setState changes the state of the port and returns true if the state or the error changed
+func (p *Port) setState(state State, err error) bool {
+	errString := ""
+	if err != nil {
+		errString = err.Error()
+	}
+
+	p.stateMutex.Lock()
+	defer p.stateMutex.Unlock()
+
+	if p.state == state && p.err == errString {
+		return false
+	}
+
+	p.state = state
+	p.err = errString
+
+	return true
+}
+
+// setState changes the state of the given ports and logs every change
+func (p *PortForwarding) setState(ports []*Port, state State, err error) {
+	for _, port := range ports {
+		if port.setState(state, err) {
+			p.logStatus(port)
+		}
+	}
+}
+
+// LogRunStart marks the start of a new run in the port forwarding log. `devspace status ports` only
+// shows the states logged after the latest marker, so that ports of earlier runs are not reported
+func LogRunStart() {
+	if forwardLog == nil {
+		forwardLog = log.GetFileLogger("portforwarding")
+	}
+
+	forwardLog.WithKey("runStart", true).Info("Port forwarding started")
+}
+
+// logStatus writes the current status of the port to the port forwarding log,
+// where it is picked up by `devspace status ports`
+func (p *PortForwarding) logStatus(port *Port) {
+	status := port.Status()
+
+	entry := forwardLog.WithKey("name", p.Name).
+		WithKey("pod", p.getPod().Name).
+		WithKey("port", port.String()).
+		WithKey("reverse", port.reverse).
+		WithKey("state", string(status.State)).
+		WithKey("bytesSent", status.BytesSent).
+		WithKey("bytesReceived", status.BytesReceived)
+
+	if status.Error != "" {
+		entry.WithKey("error", status.Error).Errorf("Port %s: %s", port.String(), status.Error)
+	} else {
+		entry.Infof("Port %s is %s", port.String(), status.State)
+	}
+}
+
+// logStats periodically writes the status of ports with new traffic to the log
+func (p *PortForwarding) logStats() {
+	lastBytes := make(map[*Port]int64)
+
+	for {
+		select {
+		case <-p.stopChan:
+			return
+		case <-time.After(statsInterval):
+		}
+
+		for _, port := range p.Ports {
+			status := port.Status()
+			bytes := status.BytesSent + status.BytesReceived
+
+			if bytes != lastBytes[port] {
+				lastBytes[port] = bytes
+				p.logStatus(port)
+			}
+		}
+	}
+}
+
+// countingWriter counts the bytes written to the underlying writer
+type countingWriter struct {
+	counter *int64
+	writer  io.Writer
+}
+
+func (c *countingWriter) Write(data []byte) (int, error) {
+	n, err := c.writer.Write(data)
+	atomic.AddInt64(c.counter, int64(n))
+
+	return n, err
+}
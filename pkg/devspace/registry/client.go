@@ -0,0 +1,506 @@
+package registry
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// dockerHubURL is the registry API endpoint of Docker Hub
+const dockerHubURL = "https://registry-1.docker.io"
+
+// manifestMediaTypes are the manifest formats the client accepts
+var manifestMediaTypes = []string{
+	"application/vnd.docker.distribution.manifest.v2+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.oci.image.index.v1+json",
+}
+
+// Manifest holds the information about an image manifest in the registry
+type Manifest struct {
+	Digest    string
+	MediaType string
+
+	// Size is the size of the config and the compressed layers, for manifest lists the size of the referenced manifests
+	Size int64
+
+	// ConfigDigest is the digest of the image config blob, it is empty for manifest lists
+	ConfigDigest string
+
+	// Layers is the number of layers, for manifest lists the number of referenced manifests
+	Layers int
+}
+
+// Client is a minimal client for the Docker Registry HTTP API V2. It supports anonymous access,
+// basic auth and the bearer token auth used by e.g. Docker Hub
+type Client struct {
+	BaseURL  string
+	Username string
+	Password string
+
+	httpClient *http.Client
+	tokens     map[string]string
+	tokenMutex sync.Mutex
+}
+
+// NewClient creates a client for the registry with the given hostname (e.g. registry.example.com:5000).
+// An empty registryURL or hub.docker.com is Docker Hub, insecure registries are accessed via plain http
+func NewClient(registryURL, username, password string, insecure bool) *Client {
+	baseURL := dockerHubURL
+
+	if registryURL != "" && registryURL != "hub.docker.com" {
+		if strings.HasPrefix(registryURL, "http://") || strings.HasPrefix(registryURL, "https://") {
+			baseURL = registryURL
+		} else if insecure {
+			baseURL = "http://" + registryURL
+		} else {
+			baseURL = "https://" + registryURL
+		}
+	}
+
+	return &Client{
+		BaseURL:  strings.TrimSuffix(baseURL, "/"),
+		Username: username,
+		Password: password,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+		tokens: map[string]string{},
+	}
+}
+
+// ManifestExists returns true if the registry has a manifest for the tag or digest in the repository
+func (c *Client) ManifestExists(repository, reference string) (bool, error) {
+	repository = c.getRepository(repository)
+
+	response, err := c.do("HEAD", "/v2/"+repository+"/manifests/"+reference, getScope(repository, "pull"), map[string]string{
+		"Accept": strings.Join(manifestMediaTypes, ", "),
+	})
+	if err != nil {
+		return false, err
+	}
+
+	defer response.Body.Close()
+
+	switch response.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, getResponseError(response)
+	}
+}
+
+// Catalog returns the repositories of the registry. Not all registries support listing the
+// repositories (e.g. Docker Hub doesn't)
+func (c *Client) Catalog() ([]string, error) {
+	repositories := []string{}
+	path := "/v2/_catalog"
+
+	for path != "" {
+		catalog := struct {
+			Repositories []string `json:"repositories"`
+		}{}
+
+		nextPath, err := c.getJSON(path, "registry:catalog:*", &catalog)
+		if err != nil {
+			return nil, err
+		}
+
+		repositories = append(repositories, catalog.Repositories...)
+		path = nextPath
+	}
+
+	return repositories, nil
+}
+
+// Tags returns the tags of the repository
+func (c *Client) Tags(repository string) ([]string, error) {
+	repository = c.getRepository(repository)
+	tags := []string{}
+	path := "/v2/" + repository + "/tags/list"
+
+	for path != "" {
+		tagList := struct {
+			Tags []string `json:"tags"`
+		}{}
+
+		nextPath, err := c.getJSON(path, getScope(repository, "pull"), &tagList)
+		if err != nil {
+			return nil, err
+		}
+
+		tags = append(tags, tagList.Tags...)
+		path = nextPath
+	}
+
+	return tags, nil
+}
+
+// GetManifest returns the digest, the media type and the size of the manifest for the tag or digest
+func (c *Client) GetManifest(repository, reference string) (*Manifest, error) {
+	repository = c.getRepository(repository)
+
+	response, err := c.do("GET", "/v2/"+repository+"/manifests/"+reference, getScope(repository, "pull"), map[string]string{
+		"Accept": strings.Join(manifestMediaTypes, ", "),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, getResponseError(response)
+	}
+
+	manifestData := struct {
+		MediaType string `json:"mediaType"`
+		Config    struct {
+			Digest string `json:"digest"`
+			Size   int64  `json:"size"`
+		} `json:"config"`
+		Layers []struct {
+			Size int64 `json:"size"`
+		} `json:"layers"`
+		Manifests []struct {
+			Size int64 `json:"size"`
+		} `json:"manifests"`
+	}{}
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading manifest %s:%s: %v", repository, reference, err)
+	}
+
+	err = json.Unmarshal(body, &manifestData)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid manifest %s:%s: %v", repository, reference, err)
+	}
+
+	manifest := &Manifest{
+		Digest:       response.Header.Get("Docker-Content-Digest"),
+		MediaType:    response.Header.Get("Content-Type"),
+		Size:         manifestData.Config.Size,
+		ConfigDigest: manifestData.Config.Digest,
+		Layers:       len(manifestData.Layers) + len(manifestData.Manifests),
+	}
+	if manifestData.MediaType != "" {
+		manifest.MediaType = manifestData.MediaType
+	}
+
+	// The digest header is optional, the digest is the hash of the manifest as sent by the registry
+	if manifest.Digest == "" {
+		manifestHash := sha256.Sum256(body)
+		manifest.Digest = "sha256:" + hex.EncodeToString(manifestHash[:])
+	}
+
+	for _, layer := range manifestData.Layers {
+		manifest.Size += layer.Size
+	}
+	for _, subManifest := range manifestData.Manifests {
+		manifest.Size += subManifest.Size
+	}
+
+	return manifest, nil
+}
+
+// GetImageCreated returns the creation date of the image from its config blob
+func (c *Client) GetImageCreated(repository, configDigest string) (time.Time, error) {
+	repository = c.getRepository(repository)
+
+	imageConfig := struct {
+		Created time.Time `json:"created"`
+	}{}
+
+	_, err := c.getJSON("/v2/"+repository+"/blobs/"+configDigest, getScope(repository, "pull"), &imageConfig)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return imageConfig.Created, nil
+}
+
+// DeleteManifest deletes the manifest with the digest and therefore all tags that reference it. The
+// registry only deletes the blobs during its next garbage collection
+func (c *Client) DeleteManifest(repository, digest string) error {
+	repository = c.getRepository(repository)
+
+	response, err := c.do("DELETE", "/v2/"+repository+"/manifests/"+digest, getScope(repository, "pull,push,delete"), nil)
+	if err != nil {
+		return err
+	}
+
+	defer response.Body.Close()
+
+	switch response.StatusCode {
+	case http.StatusOK, http.StatusAccepted:
+		return nil
+	case http.StatusMethodNotAllowed:
+		return fmt.Errorf("Registry %s doesn't allow deleting images (the registry has to run with REGISTRY_STORAGE_DELETE_ENABLED=true)", c.BaseURL)
+	default:
+		return getResponseError(response)
+	}
+}
+
+// getJSON requests the path and decodes the json response into target. It returns the path of the
+// next page if the response has a Link header (e.g. for paginated tag lists)
+func (c *Client) getJSON(path, scope string, target interface{}) (string, error) {
+	response, err := c.do("GET", path, scope, nil)
+	if err != nil {
+		return "", err
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return "", getResponseError(response)
+	}
+
+	err = json.NewDecoder(response.Body).Decode(target)
+	if err != nil {
+		return "", fmt.Errorf("Invalid response from registry %s: %v", c.BaseURL, err)
+	}
+
+	return getNextPath(response.Header.Get("Link")), nil
+}
+
+// do sends the request and authenticates if the registry requires it. The caller has to close the body
+func (c *Client) do(method, path, scope string, headers map[string]string) (*http.Response, error) {
+	response, err := c.send(method, path, c.getAuthorization(scope), headers)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode != http.StatusUnauthorized {
+		return response, nil
+	}
+
+	challenge := response.Header.Get("WWW-Authenticate")
+	response.Body.Close()
+
+	authorization, err := c.authenticate(challenge, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.send(method, path, authorization, headers)
+}
+
+func (c *Client) send(method, path, authorization string, headers map[string]string) (*http.Response, error) {
+	request, err := http.NewRequest(method, c.BaseURL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for key, value := range headers {
+		request.Header.Set(key, value)
+	}
+	if authorization != "" {
+		request.Header.Set("Authorization", authorization)
+	}
+
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to reach registry %s: %v", c.BaseURL, err)
+	}
+
+	return response, nil
+}
+
+func (c *Client) getAuthorization(scope string) string {
+	c.tokenMutex.Lock()
+	defer c.tokenMutex.Unlock()
+
+	return c.tokens[scope]
+}
+
+// authenticate answers the auth challenge of the registry and returns the authorization header value
+func (c *Client) authenticate(challenge, scope string) (string, error) {
+	authType, params := parseChallenge(challenge)
+	authorization := ""
+
+	switch authType {
+	case "basic":
+		if c.Username == "" {
+			return "", fmt.Errorf("Registry %s requires authentication", c.BaseURL)
+		}
+
+		request, _ := http.NewRequest("GET", c.BaseURL, nil)
+		request.SetBasicAuth(c.Username, c.Password)
+		authorization = request.Header.Get("Authorization")
+	case "bearer":
+		token, err := c.getToken(params, scope)
+		if err != nil {
+			return "", err
+		}
+
+		authorization = "Bearer " + token
+	default:
+		return "", fmt.Errorf("Registry %s requires unsupported authentication: %s", c.BaseURL, challenge)
+	}
+
+	c.tokenMutex.Lock()
+	defer c.tokenMutex.Unlock()
+
+	c.tokens[scope] = authorization
+
+	return authorization, nil
+}
+
+// getToken requests a bearer token for the scope from the token server of the challenge
+func (c *Client) getToken(params map[string]string, scope string) (string, error) {
+	if params["realm"] == "" {
+		return "", fmt.Errorf("Registry %s sent an auth challenge without realm", c.BaseURL)
+	}
+
+	query := url.Values{}
+	if params["service"] != "" {
+		query.Set("service", params["service"])
+	}
+	if scope != "" {
+		query.Set("scope", scope)
+	}
+
+	request, err := http.NewRequest("GET", params["realm"]+"?"+query.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	if c.Username != "" {
+		request.SetBasicAuth(c.Username, c.Password)
+	}
+
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return "", fmt.Errorf("Unable to reach token server %s: %v", params["realm"], err)
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Authentication at %s failed: %v", params["realm"], getResponseError(response))
+	}
+
+	tokenResponse := struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}{}
+
+	err = json.NewDecoder(response.Body).Decode(&tokenResponse)
+	if err != nil {
+		return "", fmt.Errorf("Invalid token response from %s: %v", params["realm"], err)
+	}
+
+	if tokenResponse.Token != "" {
+		return tokenResponse.Token, nil
+	}
+
+	return tokenResponse.AccessToken, nil
+}
+
+// parseChallenge parses a WWW-Authenticate header like: Bearer realm="https://auth.docker.io/token",service="registry.docker.io"
+func parseChallenge(challenge string) (string, map[string]string) {
+	params := map[string]string{}
+	parts := strings.SplitN(strings.TrimSpace(challenge), " ", 2)
+	authType := strings.ToLower(parts[0])
+
+	if len(parts) < 2 {
+		return authType, params
+	}
+
+	rest := parts[1]
+	for rest != "" {
+		keyValue := strings.SplitN(rest, "=", 2)
+		if len(keyValue) < 2 {
+			break
+		}
+
+		key := strings.ToLower(strings.Trim(keyValue[0], " ,"))
+		value := strings.TrimSpace(keyValue[1])
+
+		if strings.HasPrefix(value, "\"") {
+			end := strings.Index(value[1:], "\"")
+			if end < 0 {
+				params[key] = value[1:]
+				break
+			}
+
+			params[key] = value[1 : end+1]
+			rest = value[end+2:]
+		} else {
+			end := strings.Index(value, ",")
+			if end < 0 {
+				params[key] = value
+				break
+			}
+
+			params[key] = value[:end]
+			rest = value[end:]
+		}
+	}
+
+	return authType, params
+}
+
+// getRepository returns the repository name the registry expects. Official images on Docker Hub
+// are in the library namespace (e.g. nginx is library/nginx)
+func (c *Client) getRepository(repository string) string {
+	if c.BaseURL == dockerHubURL && strings.Contains(repository, "/") == false {
+		return "library/" + repository
+	}
+
+	return repository
+}
+
+// getNextPath returns the path of a Link header like: </v2/app/tags/list?n=100&last=v1>; rel="next"
+func getNextPath(link string) string {
+	start := strings.Index(link, "<")
+	end := strings.Index(link, ">")
+	if start < 0 || end < start || strings.Contains(link[end:], `rel="next"`) == false {
+		return ""
+	}
+
+	nextURL, err := url.Parse(link[start+1 : end])
+	if err != nil {
+		return ""
+	}
+
+	return nextURL.RequestURI()
+}
+
+func getScope(repository, actions string) string {
+	return "repository:" + repository + ":" + actions
+}
+
+// getResponseError returns an error with the status and the error message of the registry
+func getResponseError(response *http.Response) error {
+	body, _ := ioutil.ReadAll(io.LimitReader(response.Body, 4096))
+
+	registryErrors := struct {
+		Errors []struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	}{}
+
+	if json.Unmarshal(body, &registryErrors) == nil && len(registryErrors.Errors) > 0 {
+		messages := make([]string, 0, len(registryErrors.Errors))
+		for _, registryError := range registryErrors.Errors {
+			messages = append(messages, registryError.Code+": "+registryError.Message)
+		}
+
+		return fmt.Errorf("Registry responded with %s (%s)", response.Status, strings.Join(messages, ", "))
+	}
+
+	return fmt.Errorf("Registry responded with %s", response.Status)
+}
@@ -0,0 +1,177 @@
+package registry
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestManifestExistsWithBearerAuth(t *testing.T) {
+	tokenRequests := 0
+
+	var registryServer *httptest.Server
+	registryServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			username, password, ok := r.BasicAuth()
+			if ok == false || username != "user" || password != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if r.URL.Query().Get("scope") != "repository:user/app:pull" || r.URL.Query().Get("service") != "test-registry" {
+				t.Errorf("Unexpected token request %s", r.URL.RawQuery)
+			}
+
+			tokenRequests++
+			w.Write([]byte(`{"token": "abc"}`))
+		case "/v2/user/app/manifests/v1", "/v2/user/app/manifests/v2":
+			if r.Header.Get("Authorization") != "Bearer abc" {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="`+registryServer.URL+`/token",service="test-registry",scope="repository:user/app:pull"`)
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			if r.URL.Path == "/v2/user/app/manifests/v1" {
+				w.WriteHeader(http.StatusOK)
+			} else {
+				w.WriteHeader(http.StatusNotFound)
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer registryServer.Close()
+
+	client := NewClient(registryServer.URL, "user", "secret", false)
+
+	exists, err := client.ManifestExists("user/app", "v1")
+	if err != nil || exists == false {
+		t.Fatalf("Expected manifest v1 to exist, got %v, %v", exists, err)
+	}
+
+	exists, err = client.ManifestExists("user/app", "v2")
+	if err != nil || exists {
+		t.Fatalf("Expected manifest v2 to be missing, got %v, %v", exists, err)
+	}
+
+	if tokenRequests != 1 {
+		t.Fatalf("Expected the token to be reused, got %d token requests", tokenRequests)
+	}
+
+	client = NewClient(registryServer.URL, "user", "wrong", false)
+
+	_, err = client.ManifestExists("user/app", "v1")
+	if err == nil {
+		t.Fatal("Expected an error for wrong credentials")
+	}
+}
+
+func TestManifestExistsWithBasicAuth(t *testing.T) {
+	registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		if ok == false || username != "user" || password != "secret" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="Registry Realm"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer registryServer.Close()
+
+	exists, err := NewClient(registryServer.URL, "user", "secret", false).ManifestExists("app", "latest")
+	if err != nil || exists == false {
+		t.Fatalf("Expected manifest to exist, got %v, %v", exists, err)
+	}
+
+	_, err = NewClient(registryServer.URL, "", "", false).ManifestExists("app", "latest")
+	if err == nil {
+		t.Fatal("Expected an error without credentials")
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	testCases := map[string]string{
+		"":                      dockerHubURL,
+		"hub.docker.com":        dockerHubURL,
+		"registry.example.com":  "https://registry.example.com",
+		"http://localhost:5000": "http://localhost:5000",
+	}
+
+	for registryURL, expected := range testCases {
+		if baseURL := NewClient(registryURL, "", "", false).BaseURL; baseURL != expected {
+			t.Errorf("Expected %s for %s, got %s", expected, registryURL, baseURL)
+		}
+	}
+
+	if baseURL := NewClient("10.0.0.1:5000", "", "", true).BaseURL; baseURL != "http://10.0.0.1:5000" {
+		t.Errorf("Expected plain http for insecure registries, got %s", baseURL)
+	}
+}
+
+func TestParseChallenge(t *testing.T) {
+	authType, params := parseChallenge(`Bearer realm="https://auth.docker.io/token",service="registry.docker.io",scope="repository:user/app:pull,push"`)
+	expected := map[string]string{
+		"realm":   "https://auth.docker.io/token",
+		"service": "registry.docker.io",
+		"scope":   "repository:user/app:pull,push",
+	}
+
+	if authType != "bearer" || reflect.DeepEqual(params, expected) == false {
+		t.Fatalf("Unexpected challenge %s %v", authType, params)
+	}
+
+	authType, params = parseChallenge(`Basic realm=registry`)
+	if authType != "basic" || params["realm"] != "registry" {
+		t.Fatalf("Unexpected challenge %s %v", authType, params)
+	}
+}
+
+func TestCatalog(t *testing.T) {
+	registryServer := httptest.NewServer(newFakeRegistry())
+	defer registryServer.Close()
+
+	repositories, err := NewClient(registryServer.URL, "", "", false).Catalog()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if reflect.DeepEqual(repositories, []string{"user/app", "user/db"}) == false {
+		t.Fatalf("Unexpected repositories %v", repositories)
+	}
+}
+
+func TestGetManifest(t *testing.T) {
+	registryServer := httptest.NewServer(newFakeRegistry())
+	defer registryServer.Close()
+
+	client := NewClient(registryServer.URL, "", "", false)
+
+	manifest, err := client.GetManifest("user/db", "5.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &Manifest{
+		Digest:       "sha256:f",
+		MediaType:    "application/vnd.docker.distribution.manifest.v2+json",
+		Size:         2000,
+		ConfigDigest: "config-sha256:f",
+		Layers:       1,
+	}
+	if reflect.DeepEqual(manifest, expected) == false {
+		t.Fatalf("Expected manifest %+v, got %+v", expected, manifest)
+	}
+
+	created, err := client.GetImageCreated("user/db", manifest.ConfigDigest)
+	if err != nil || created.IsZero() {
+		t.Fatalf("Expected the creation date, got %v, %v", created, err)
+	}
+
+	_, err = client.GetManifest("user/db", "6.0")
+	if err == nil || strings.Contains(err.Error(), "404") == false {
+		t.Fatalf("Expected a not found error, got %v", err)
+	}
+}
@@ -0,0 +1,91 @@
+package registry
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/covexo/devspace/pkg/devspace/clients/kubectl"
+	"github.com/covexo/devspace/pkg/devspace/config/v1"
+	"github.com/covexo/devspace/pkg/devspace/portforward"
+	"github.com/covexo/yamlq"
+	k8sv1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// registryConfigPath is the path of the registry config inside the pod of the internal registry
+const registryConfigPath = "/etc/docker/registry/config.yml"
+
+// portForwardTimeout is the time to wait for the port forwarding to the internal registry
+const portForwardTimeout = 20 * time.Second
+
+// NewInternalRegistryClient creates a client for the internal registry. Without ingress, the registry is only
+// reachable inside the cluster, so its pod is forwarded to a local port until the returned stop channel is closed
+func NewInternalRegistryClient(kubectlClient *kubernetes.Clientset, internalRegistry *v1.InternalRegistry, registryConfig *v1.RegistryConfig) (*Client, chan struct{}, error) {
+	stopChan := make(chan struct{})
+
+	if internalRegistry.Release.Values != nil {
+		isIngressEnabled, _ := yamlq.NewQuery(*internalRegistry.Release.Values).Bool("ingress", "enabled")
+		if isIngressEnabled {
+			return NewClientFromConfig(registryConfig), stopChan, nil
+		}
+	}
+
+	pod, err := getInternalRegistryPod(kubectlClient, internalRegistry)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	localPort, err := portforward.GetFreePort(portforward.DefaultBindAddress)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	readyChan := make(chan struct{})
+	errorChan := make(chan error, 1)
+
+	go func() {
+		errorChan <- kubectl.ForwardPorts(kubectlClient, pod, []string{strconv.Itoa(localPort) + ":" + strconv.Itoa(registryPort)}, stopChan, readyChan)
+	}()
+
+	select {
+	case <-readyChan:
+	case err = <-errorChan:
+		return nil, nil, fmt.Errorf("Unable to forward the internal registry: %v", err)
+	case <-time.After(portForwardTimeout):
+		close(stopChan)
+		return nil, nil, fmt.Errorf("Timeout forwarding the internal registry pod %s", pod.Name)
+	}
+
+	client := NewClientFromConfig(registryConfig)
+	client.BaseURL = "http://" + portforward.DefaultBindAddress + ":" + strconv.Itoa(localPort)
+
+	return client, stopChan, nil
+}
+
+// GarbageCollectInternalRegistry runs the garbage collection in the pod of the internal registry, which
+// deletes the blobs that are no longer referenced by a manifest. It returns the output of the registry
+func GarbageCollectInternalRegistry(kubectlClient *kubernetes.Clientset, internalRegistry *v1.InternalRegistry) (string, error) {
+	pod, err := getInternalRegistryPod(kubectlClient, internalRegistry)
+	if err != nil {
+		return "", err
+	}
+
+	stdout, stderr, err := kubectl.ExecBuffered(kubectlClient, pod, "", []string{"registry", "garbage-collect", registryConfigPath})
+	if err != nil {
+		return "", fmt.Errorf("Error running garbage collection: %v", err)
+	}
+
+	return string(stdout) + string(stderr), nil
+}
+
+func getInternalRegistryPod(kubectlClient *kubernetes.Clientset, internalRegistry *v1.InternalRegistry) (*k8sv1.Pod, error) {
+	registryDeploymentName := *internalRegistry.Release.Name + "-docker-registry"
+
+	pod, err := kubectl.GetDeploymentPod(kubectlClient, registryDeploymentName, "", *internalRegistry.Release.Namespace)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to find the pod of the internal registry: %v", err)
+	}
+
+	return pod, nil
+}
@@ -0,0 +1,116 @@
+package registry
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// TagInfo holds the manifest and the creation date of a tag
+type TagInfo struct {
+	Tag     string
+	Digest  string
+	Size    int64
+	Created time.Time
+}
+
+// PruneResult lists the tags that were deleted from a repository and the tags that were kept
+type PruneResult struct {
+	Deleted []*TagInfo
+	Kept    []*TagInfo
+}
+
+// GetTagInfos returns the tags of the repository with their manifests, ordered from newest to oldest.
+// Tags of images without creation date (e.g. manifest lists) are ordered by name after the others
+func GetTagInfos(client *Client, repository string) ([]*TagInfo, error) {
+	tags, err := client.Tags(repository)
+	if err != nil {
+		return nil, err
+	}
+
+	tagInfos := make([]*TagInfo, 0, len(tags))
+
+	for _, tag := range tags {
+		manifest, err := client.GetManifest(repository, tag)
+		if err != nil {
+			return nil, fmt.Errorf("Error reading manifest of %s:%s: %v", repository, tag, err)
+		}
+
+		tagInfo := &TagInfo{
+			Tag:    tag,
+			Digest: manifest.Digest,
+			Size:   manifest.Size,
+		}
+
+		if manifest.ConfigDigest != "" {
+			tagInfo.Created, err = client.GetImageCreated(repository, manifest.ConfigDigest)
+			if err != nil {
+				return nil, fmt.Errorf("Error reading image config of %s:%s: %v", repository, tag, err)
+			}
+		}
+
+		tagInfos = append(tagInfos, tagInfo)
+	}
+
+	sort.SliceStable(tagInfos, func(i, j int) bool {
+		if tagInfos[i].Created.Equal(tagInfos[j].Created) {
+			return tagInfos[i].Tag < tagInfos[j].Tag
+		}
+
+		return tagInfos[i].Created.After(tagInfos[j].Created)
+	})
+
+	return tagInfos, nil
+}
+
+// PruneRepository deletes all but the newest keep tags of the repository. The protected tags and digests
+// (e.g. the deployed image) are never deleted. Tags without creation date (e.g. manifest lists or a BuildKit
+// cache) can't be ordered by age, so they are kept as well. Because a manifest is deleted with all of its
+// tags, tags that share the manifest of a kept tag are kept too. With dryRun, nothing is deleted
+func PruneRepository(client *Client, repository string, keep int, protectedTags, protectedDigests []string, dryRun bool) (*PruneResult, error) {
+	tagInfos, err := GetTagInfos(client, repository)
+	if err != nil {
+		return nil, err
+	}
+
+	isProtectedTag := map[string]bool{}
+	for _, tag := range protectedTags {
+		isProtectedTag[tag] = true
+	}
+
+	keptDigests := map[string]bool{}
+	for _, digest := range protectedDigests {
+		keptDigests[digest] = true
+	}
+
+	for index, tagInfo := range tagInfos {
+		if index < keep || isProtectedTag[tagInfo.Tag] || tagInfo.Created.IsZero() {
+			keptDigests[tagInfo.Digest] = true
+		}
+	}
+
+	result := &PruneResult{
+		Deleted: []*TagInfo{},
+		Kept:    []*TagInfo{},
+	}
+	deletedDigests := map[string]bool{}
+
+	for _, tagInfo := range tagInfos {
+		if keptDigests[tagInfo.Digest] {
+			result.Kept = append(result.Kept, tagInfo)
+			continue
+		}
+
+		if dryRun == false && deletedDigests[tagInfo.Digest] == false {
+			err = client.DeleteManifest(repository, tagInfo.Digest)
+			if err != nil {
+				return result, fmt.Errorf("Error deleting %s:%s: %v", repository, tagInfo.Tag, err)
+			}
+		}
+
+		deletedDigests[tagInfo.Digest] = true
+		result.Deleted = append(result.Deleted, tagInfo)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,313 @@
+package registry
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeImage is an image in the fake registry
+type fakeImage struct {
+	digest  string
+	created time.Time
+	size    int64
+
+	// Manifest lists have no image config and therefore no creation date
+	manifestList bool
+}
+
+// fakeRegistry is a minimal in-memory Docker Registry HTTP API V2 with paginated tag lists
+type fakeRegistry struct {
+	repositories map[string]map[string]*fakeImage
+	deleted      []string
+	mutex        sync.Mutex
+}
+
+func (f *fakeRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	path := strings.TrimPrefix(r.URL.Path, "/v2/")
+
+	if path == "_catalog" {
+		repositories := []string{}
+		for repository := range f.repositories {
+			repositories = append(repositories, repository)
+		}
+
+		sort.Strings(repositories)
+		json.NewEncoder(w).Encode(map[string][]string{"repositories": repositories})
+		return
+	}
+
+	for repository, tags := range f.repositories {
+		if strings.HasPrefix(path, repository+"/") == false {
+			continue
+		}
+
+		parts := strings.SplitN(strings.TrimPrefix(path, repository+"/"), "/", 2)
+
+		switch {
+		case parts[0] == "tags" && parts[1] == "list":
+			f.serveTags(w, r, repository, tags)
+		case parts[0] == "manifests" && r.Method == "DELETE":
+			found := false
+			for tag, image := range tags {
+				if image.digest == parts[1] {
+					delete(tags, tag)
+					found = true
+				}
+			}
+
+			if found == false {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+
+			f.deleted = append(f.deleted, parts[1])
+			w.WriteHeader(http.StatusAccepted)
+		case parts[0] == "manifests":
+			image := tags[parts[1]]
+			if image == nil {
+				for _, taggedImage := range tags {
+					if taggedImage.digest == parts[1] {
+						image = taggedImage
+					}
+				}
+			}
+			if image == nil {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+
+			w.Header().Set("Docker-Content-Digest", image.digest)
+
+			if image.manifestList {
+				w.Header().Set("Content-Type", "application/vnd.docker.distribution.manifest.list.v2+json")
+				json.NewEncoder(w).Encode(map[string]interface{}{
+					"schemaVersion": 2,
+					"mediaType":     "application/vnd.docker.distribution.manifest.list.v2+json",
+					"manifests":     []map[string]interface{}{{"size": image.size}},
+				})
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/vnd.docker.distribution.manifest.v2+json")
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"schemaVersion": 2,
+				"mediaType":     "application/vnd.docker.distribution.manifest.v2+json",
+				"config":        map[string]interface{}{"digest": "config-" + image.digest, "size": 1000},
+				"layers":        []map[string]interface{}{{"size": image.size - 1000}},
+			})
+		case parts[0] == "blobs":
+			for _, image := range tags {
+				if "config-"+image.digest == parts[1] {
+					json.NewEncoder(w).Encode(map[string]interface{}{"created": image.created})
+					return
+				}
+			}
+
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+
+		return
+	}
+
+	w.WriteHeader(http.StatusNotFound)
+}
+
+// serveTags returns two tags per page like registries with a small page size
+func (f *fakeRegistry) serveTags(w http.ResponseWriter, r *http.Request, repository string, tags map[string]*fakeImage) {
+	tagNames := []string{}
+	for tag := range tags {
+		if tag > r.URL.Query().Get("last") {
+			tagNames = append(tagNames, tag)
+		}
+	}
+
+	sort.Strings(tagNames)
+
+	if len(tagNames) > 2 {
+		tagNames = tagNames[:2]
+		w.Header().Set("Link", `</v2/`+repository+`/tags/list?n=2&last=`+tagNames[1]+`>; rel="next"`)
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{"name": repository, "tags": tagNames})
+}
+
+func newFakeRegistry() *fakeRegistry {
+	now := time.Now()
+
+	return &fakeRegistry{
+		repositories: map[string]map[string]*fakeImage{
+			"user/app": {
+				"a": {digest: "sha256:a", created: now.Add(-5 * time.Hour), size: 5000},
+				"b": {digest: "sha256:b", created: now.Add(-4 * time.Hour), size: 5000},
+				"c": {digest: "sha256:c", created: now.Add(-3 * time.Hour), size: 5000},
+				"d": {digest: "sha256:d", created: now.Add(-2 * time.Hour), size: 5000},
+				"e": {digest: "sha256:e", created: now.Add(-1 * time.Hour), size: 5000},
+				// latest points to the same manifest as the old tag b
+				"latest": {digest: "sha256:b", created: now.Add(-4 * time.Hour), size: 5000},
+			},
+			"user/db": {
+				"5.0": {digest: "sha256:f", created: now, size: 2000},
+			},
+		},
+	}
+}
+
+func TestGetTagInfos(t *testing.T) {
+	registryServer := httptest.NewServer(newFakeRegistry())
+	defer registryServer.Close()
+
+	tagInfos, err := GetTagInfos(NewClient(registryServer.URL, "", "", false), "user/app")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tags := []string{}
+	for _, tagInfo := range tagInfos {
+		tags = append(tags, tagInfo.Tag)
+	}
+
+	expected := []string{"e", "d", "c", "b", "latest", "a"}
+	if reflect.DeepEqual(tags, expected) == false {
+		t.Fatalf("Expected tags %v, got %v", expected, tags)
+	}
+
+	if tagInfos[0].Digest != "sha256:e" || tagInfos[0].Size != 5000 || tagInfos[0].Created.IsZero() {
+		t.Fatalf("Unexpected tag info %+v", tagInfos[0])
+	}
+}
+
+func TestPruneRepository(t *testing.T) {
+	fake := newFakeRegistry()
+	registryServer := httptest.NewServer(fake)
+	defer registryServer.Close()
+
+	client := NewClient(registryServer.URL, "", "", false)
+
+	// The deployed tag a is older than the kept tags, b is kept because latest shares its manifest
+	result, err := PruneRepository(client, "user/app", 1, []string{"a", "latest"}, []string{}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(fake.deleted) != 0 {
+		t.Fatalf("Expected no deletes in a dry run, got %v", fake.deleted)
+	}
+
+	deleted := []string{}
+	for _, tagInfo := range result.Deleted {
+		deleted = append(deleted, tagInfo.Tag)
+	}
+	if reflect.DeepEqual(deleted, []string{"d", "c"}) == false {
+		t.Fatalf("Expected d and c to be deleted, got %v", deleted)
+	}
+
+	result, err = PruneRepository(client, "user/app", 1, []string{"a"}, []string{"sha256:c"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sort.Strings(fake.deleted)
+	if reflect.DeepEqual(fake.deleted, []string{"sha256:b", "sha256:d"}) == false {
+		t.Fatalf("Expected b and d to be deleted, got %v", fake.deleted)
+	}
+	if len(result.Deleted) != 3 || len(result.Kept) != 3 {
+		t.Fatalf("Expected 3 deleted and 3 kept tags, got %d and %d", len(result.Deleted), len(result.Kept))
+	}
+
+	tags, err := client.Tags("user/app")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sort.Strings(tags)
+	if reflect.DeepEqual(tags, []string{"a", "c", "e"}) == false {
+		t.Fatalf("Expected tags a, c and e to remain, got %v", tags)
+	}
+}
+
+func TestPruneRepositoryKeepsUndatedTags(t *testing.T) {
+	now := time.Now()
+	fake := &fakeRegistry{
+		repositories: map[string]map[string]*fakeImage{
+			"user/app": {
+				"a":          {digest: "sha256:a", created: now.Add(-2 * time.Hour), size: 5000},
+				"b":          {digest: "sha256:b", created: now.Add(-1 * time.Hour), size: 5000},
+				"buildcache": {digest: "sha256:cache", size: 9000, manifestList: true},
+				"multiarch":  {digest: "sha256:multiarch", size: 9000, manifestList: true},
+			},
+		},
+	}
+
+	registryServer := httptest.NewServer(fake)
+	defer registryServer.Close()
+
+	result, err := PruneRepository(NewClient(registryServer.URL, "", "", false), "user/app", 1, []string{}, []string{}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if reflect.DeepEqual(fake.deleted, []string{"sha256:a"}) == false {
+		t.Fatalf("Expected only a to be deleted, got %v", fake.deleted)
+	}
+	if len(result.Kept) != 3 {
+		t.Fatalf("Expected 3 kept tags, got %d", len(result.Kept))
+	}
+}
+
+func TestDeleteManifestDisabled(t *testing.T) {
+	registryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}))
+	defer registryServer.Close()
+
+	err := NewClient(registryServer.URL, "", "", false).DeleteManifest("user/app", "sha256:a")
+	if err == nil || strings.Contains(err.Error(), "REGISTRY_STORAGE_DELETE_ENABLED") == false {
+		t.Fatalf("Expected an error about disabled deletes, got %v", err)
+	}
+}
+
+func TestGetNextPath(t *testing.T) {
+	testCases := map[string]string{
+		`</v2/app/tags/list?n=2&last=b>; rel="next"`:                      "/v2/app/tags/list?n=2&last=b",
+		`<https://registry.example.com/v2/_catalog?last=app>; rel="next"`: "/v2/_catalog?last=app",
+		"": "",
+	}
+
+	for link, expected := range testCases {
+		if nextPath := getNextPath(link); nextPath != expected {
+			t.Errorf("Expected %s for %s, got %s", expected, link, nextPath)
+		}
+	}
+}
+
+func TestGetInternalRegistryValues(t *testing.T) {
+	values := map[interface{}]interface{}{
+		"configData": map[interface{}]interface{}{
+			"storage": map[interface{}]interface{}{
+				"cache": "inmemory",
+			},
+		},
+	}
+
+	registryValues := *getInternalRegistryValues(&values)
+	storage := registryValues["configData"].(map[interface{}]interface{})["storage"].(map[interface{}]interface{})
+
+	if storage["cache"] != "inmemory" || storage["delete"].(map[interface{}]interface{})["enabled"] != true {
+		t.Fatalf("Expected deleting to be enabled, got %v", storage)
+	}
+	if _, ok := values["configData"].(map[interface{}]interface{})["storage"].(map[interface{}]interface{})["delete"]; ok {
+		t.Fatal("Expected the config values to be unchanged")
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/covexo/devspace/pkg/devspace/builder/docker"
 	"github.com/covexo/devspace/pkg/devspace/config/v1"
 
 	"github.com/covexo/devspace/pkg/util/log"
@@ -20,6 +21,7 @@ import (
 	"github.com/covexo/yamlq"
 
 	k8sv1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -63,6 +65,11 @@ func CreatePullSecret(kubectl *kubernetes.Clientset, namespace, registryURL, use
 
 	if err != nil {
 		_, err = kubectl.Core().Secrets(namespace).Create(registryPullSecret)
+
+		// The secret could have been created by a build running in parallel
+		if k8serrors.IsAlreadyExists(err) {
+			_, err = kubectl.Core().Secrets(namespace).Update(registryPullSecret)
+		}
 	} else {
 		_, err = kubectl.Core().Secrets(namespace).Update(registryPullSecret)
 	}
@@ -84,7 +91,7 @@ func GetRegistryAuthSecretName(registryURL string) string {
 func InitInternalRegistry(kubectl *kubernetes.Clientset, helm *helm.HelmClientWrapper, internalRegistry *v1.InternalRegistry, registryConfig *v1.RegistryConfig) error {
 	registryReleaseName := *internalRegistry.Release.Name
 	registryReleaseNamespace := *internalRegistry.Release.Namespace
-	registryReleaseValues := internalRegistry.Release.Values
+	registryReleaseValues := getInternalRegistryValues(internalRegistry.Release.Values)
 
 	// Check if registry namespace exists
 	_, err := kubectl.CoreV1().Namespaces().Get(registryReleaseNamespace, metav1.GetOptions{})
@@ -208,6 +215,43 @@ func InitInternalRegistry(kubectl *kubernetes.Clientset, helm *helm.HelmClientWr
 	return nil
 }
 
+// getInternalRegistryValues returns the chart values of the internal registry with deleting enabled (unless
+// configured otherwise), so that devspace registry prune can delete old images. The config values are not changed
+func getInternalRegistryValues(values *map[interface{}]interface{}) *map[interface{}]interface{} {
+	registryValues := map[interface{}]interface{}{}
+	if values != nil {
+		for key, value := range *values {
+			registryValues[key] = value
+		}
+	}
+
+	configData := copyValuesMap(registryValues["configData"])
+	storage := copyValuesMap(configData["storage"])
+	deleteConfig := copyValuesMap(storage["delete"])
+
+	if _, ok := deleteConfig["enabled"]; ok == false {
+		deleteConfig["enabled"] = true
+	}
+
+	storage["delete"] = deleteConfig
+	configData["storage"] = storage
+	registryValues["configData"] = configData
+
+	return &registryValues
+}
+
+func copyValuesMap(value interface{}) map[interface{}]interface{} {
+	valuesMap := map[interface{}]interface{}{}
+
+	if existingMap, ok := value.(map[interface{}]interface{}); ok {
+		for key, value := range existingMap {
+			valuesMap[key] = value
+		}
+	}
+
+	return valuesMap
+}
+
 func waitForRegistry(registryNamespace, registryReleaseDeploymentName string, client *kubernetes.Clientset) error {
 	registryWaitingTime := 2 * 60 * time.Second
 	registryCheckInverval := 5 * time.Second
@@ -231,18 +275,21 @@ func waitForRegistry(registryNamespace, registryReleaseDeploymentName string, cl
 	return errors.New("Internal registry start waiting time timed out")
 }
 
-//GetImageURL returns the image (optional with tag)
+//GetImageURL returns the image (optional with tag). Images without registry (e.g. images that are not
+//built by DevSpace) are returned with their name only, i.e. they are pulled from Docker Hub
 func GetImageURL(imageConfig *v1.ImageConfig, includingLatestTag bool) string {
-	registryConfig, registryConfErr := GetRegistryConfig(imageConfig)
-
-	if registryConfErr != nil {
-		log.Fatal(registryConfErr)
-	}
 	image := *imageConfig.Name
-	registryURL := *registryConfig.URL
 
-	if registryURL != "" && registryURL != "hub.docker.com" {
-		image = registryURL + "/" + image
+	if imageConfig.Registry != nil {
+		registryConfig, registryConfErr := GetRegistryConfig(imageConfig)
+
+		if registryConfErr != nil {
+			log.Fatal(registryConfErr)
+		}
+
+		if registryConfig.URL != nil && *registryConfig.URL != "" && *registryConfig.URL != "hub.docker.com" {
+			image = *registryConfig.URL + "/" + image
+		}
 	}
 
 	if includingLatestTag {
@@ -251,8 +298,22 @@ func GetImageURL(imageConfig *v1.ImageConfig, includingLatestTag bool) string {
 	return image
 }
 
+//GetDeployImageURL returns the image with its tag or, if deployByDigest is enabled and the digest of
+//the last push is known, with its digest (e.g. registry/name@sha256:...)
+func GetDeployImageURL(imageConfig *v1.ImageConfig) string {
+	if imageConfig.DeployByDigest != nil && *imageConfig.DeployByDigest && imageConfig.Digest != nil && *imageConfig.Digest != "" {
+		return GetImageURL(imageConfig, false) + "@" + *imageConfig.Digest
+	}
+
+	return GetImageURL(imageConfig, true)
+}
+
 // GetRegistryConfig returns the registry config for an image or an error if the registry is not defined
 func GetRegistryConfig(imageConfig *v1.ImageConfig) (*v1.RegistryConfig, error) {
+	if imageConfig.Registry == nil {
+		return nil, errors.New("Image has no registry")
+	}
+
 	config := configutil.GetConfig(false)
 	registryName := *imageConfig.Registry
 	registryMap := *config.Registries
@@ -263,3 +324,36 @@ func GetRegistryConfig(imageConfig *v1.ImageConfig) (*v1.RegistryConfig, error)
 	}
 	return registryConfig, nil
 }
+
+// NewClientFromConfig creates a registry API client with the credentials of the registry config. Without
+// configured credentials, the credentials of the local docker config are used (e.g. from docker login)
+func NewClientFromConfig(registryConfig *v1.RegistryConfig) *Client {
+	registryURL := ""
+	username := ""
+	password := ""
+	insecure := false
+
+	if registryConfig.URL != nil {
+		registryURL = *registryConfig.URL
+	}
+	if registryConfig.Insecure != nil {
+		insecure = *registryConfig.Insecure
+	}
+
+	if registryConfig.Auth != nil && registryConfig.Auth.Username != nil && *registryConfig.Auth.Username != "" {
+		username = *registryConfig.Auth.Username
+
+		if registryConfig.Auth.Password != nil {
+			password = *registryConfig.Auth.Password
+		}
+	} else if authConfig, err := docker.GetRegistryAuth(registryURL); err == nil {
+		username = authConfig.Username
+		password = authConfig.Password
+
+		if password == "" {
+			password = authConfig.IdentityToken
+		}
+	}
+
+	return NewClient(registryURL, username, password, insecure)
+}
@@ -0,0 +1,24 @@
+package registry
+
+import (
+	"testing"
+
+	"github.com/covexo/devspace/pkg/devspace/config/v1"
+)
+
+func TestGetImageURLWithoutRegistry(t *testing.T) {
+	name := "redis"
+	tag := "5.0-alpine"
+
+	imageConf := &v1.ImageConfig{
+		Name: &name,
+		Tag:  &tag,
+	}
+
+	if imageURL := GetImageURL(imageConf, false); imageURL != "redis" {
+		t.Fatalf("Expected redis, got %s", imageURL)
+	}
+	if imageURL := GetImageURL(imageConf, true); imageURL != "redis:5.0-alpine" {
+		t.Fatalf("Expected redis:5.0-alpine, got %s", imageURL)
+	}
+}
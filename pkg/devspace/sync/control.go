@@ -0,0 +1,215 @@
+package sync
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/juju/errors"
+	"github.com/rjeczalik/notify"
+)
+
+// Pause stops the sync from applying changes in both directions. Local changes that happen
+// while the sync is paused are buffered and reconciled when the sync is resumed
+func (s *SyncConfig) Pause() {
+	s.pauseMutex.Lock()
+	defer s.pauseMutex.Unlock()
+
+	if s.paused {
+		return
+	}
+
+	s.paused = true
+	s.pausedPaths = make(map[string]bool)
+
+	s.Logf("[Sync] Sync paused")
+}
+
+// Resume resumes a paused sync and uploads all local changes that happened in the meantime
+func (s *SyncConfig) Resume() error {
+	s.pauseMutex.Lock()
+
+	if s.paused == false {
+		s.pauseMutex.Unlock()
+		return nil
+	}
+
+	s.paused = false
+
+	// The postponed initial sync compares the complete local and remote state, so the buffered paths are not needed
+	initialSyncResume := s.initialSyncResume
+	if initialSyncResume != nil {
+		s.initialSyncResume = nil
+		s.pausedPaths = nil
+	}
+
+	s.pauseMutex.Unlock()
+
+	s.Logf("[Sync] Sync resumed")
+
+	if initialSyncResume != nil {
+		close(initialSyncResume)
+		return nil
+	}
+
+	// Let the upstream reconcile the local state with the file index
+	err := s.sendFlush(s.upstream.resume, s.upstream.interrupt)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	return nil
+}
+
+// Flush applies all pending changes immediately in both directions and returns when they are applied
+func (s *SyncConfig) Flush() error {
+	if s.IsPaused() {
+		return errors.New("Sync is paused")
+	}
+
+	// Upload first so that the downstream doesn't see half applied local changes
+	err := s.sendFlush(s.upstream.flush, s.upstream.interrupt)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	err = s.sendFlush(s.downstream.flush, s.downstream.interrupt)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	return nil
+}
+
+// IsPaused returns if the sync is currently paused
+func (s *SyncConfig) IsPaused() bool {
+	s.pauseMutex.Lock()
+	defer s.pauseMutex.Unlock()
+
+	return s.paused
+}
+
+// PendingChanges returns the amount of local paths that changed while the sync was paused
+func (s *SyncConfig) PendingChanges() int {
+	s.pauseMutex.Lock()
+	defer s.pauseMutex.Unlock()
+
+	return len(s.pausedPaths)
+}
+
+func (s *SyncConfig) sendFlush(flushChan chan chan error, interrupt chan bool) error {
+	done := make(chan error, 1)
+
+	select {
+	case flushChan <- done:
+	case <-interrupt:
+		return errors.New("Sync is stopped")
+	}
+
+	select {
+	case err := <-done:
+		return err
+	case <-interrupt:
+		return errors.New("Sync is stopped")
+	}
+}
+
+// bufferPaths remembers the given local paths, it returns false if the sync is not paused
+func (s *SyncConfig) bufferPaths(fullpaths []string) bool {
+	s.pauseMutex.Lock()
+	defer s.pauseMutex.Unlock()
+
+	if s.paused == false {
+		return false
+	}
+
+	for _, fullpath := range fullpaths {
+		s.pausedPaths[fullpath] = true
+	}
+
+	return true
+}
+
+// reconcile compares the buffered paths, the local directory and the file index and returns the
+// changes that are necessary to bring the container up to date
+func (u *upstream) reconcile() []*fileInformation {
+	u.config.pauseMutex.Lock()
+	fullpaths := u.config.pausedPaths
+	u.config.pausedPaths = nil
+	u.config.pauseMutex.Unlock()
+
+	if fullpaths == nil {
+		fullpaths = make(map[string]bool)
+	}
+
+	// Events can get lost on some platforms, so we also walk the complete local tree
+	filepath.Walk(u.config.WatchPath, func(fullpath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+
+		// Don't descend into excluded folders
+		if info.IsDir() && u.config.ignoreMatcher != nil && u.config.ignoreMatcher.MatchesPath(getRelativeFromFullPath(fullpath, u.config.WatchPath)) {
+			return filepath.SkipDir
+		}
+
+		fullpaths[fullpath] = true
+		return nil
+	})
+
+	u.config.fileIndex.fileMapMutex.Lock()
+	defer u.config.fileIndex.fileMapMutex.Unlock()
+
+	fileMap := u.config.fileIndex.fileMap
+
+	// Paths that are tracked but do not exist locally anymore
+	for relativePath := range fileMap {
+		fullpaths[filepath.Join(u.config.WatchPath, relativePath)] = true
+	}
+
+	sortedPaths := make([]string, 0, len(fullpaths))
+	for fullpath := range fullpaths {
+		sortedPaths = append(sortedPaths, fullpath)
+	}
+
+	// Parents are evaluated before their children
+	sort.Strings(sortedPaths)
+
+	removes := make([]*fileInformation, 0, 10)
+	creates := make([]*fileInformation, 0, 10)
+
+	for _, fullpath := range sortedPaths {
+		relativePath := getRelativeFromFullPath(fullpath, u.config.WatchPath)
+		if relativePath == "" {
+			continue
+		}
+
+		change := evaluateChange(u.config, fileMap, relativePath, fullpath)
+		if change == nil {
+			continue
+		}
+
+		if change.Mtime > 0 {
+			creates = append(creates, change)
+		} else {
+			removes = append(removes, change)
+		}
+	}
+
+	return append(removes, creates...)
+}
+
+func (u *upstream) eventPaths(events []notify.EventInfo) []string {
+	fullpaths := make([]string, 0, len(events))
+
+	for _, event := range events {
+		// Changes from the initial sync only carry the relative path
+		if fileInfo, ok := event.(*fileInformation); ok {
+			fullpaths = append(fullpaths, filepath.Join(u.config.WatchPath, fileInfo.Name))
+		} else {
+			fullpaths = append(fullpaths, event.Path())
+		}
+	}
+
+	return fullpaths
+}
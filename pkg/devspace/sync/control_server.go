@@ -0,0 +1,204 @@
+package sync
+
+import (
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/juju/errors"
+)
+
+// ControlSocket is the relative path of the unix socket that controls the running syncs
+var ControlSocket = ".devspace/sync.sock"
+
+// Control commands that are understood by the control server
+const (
+	ControlPause  = "pause"
+	ControlResume = "resume"
+	ControlFlush  = "flush"
+	ControlStatus = "status"
+)
+
+// ControlRequest is sent by the client to the control server
+type ControlRequest struct {
+	Command string
+}
+
+// ControlResponse is sent back by the control server
+type ControlResponse struct {
+	Error string
+	Syncs []*ControlSyncStatus
+}
+
+// ControlSyncStatus holds the state of a single sync path
+type ControlSyncStatus struct {
+	Pod            string
+	Local          string
+	Container      string
+	Paused         bool
+	PendingChanges int
+	Error          string
+}
+
+// ControlServer listens on the control socket and applies the received commands to all syncs
+type ControlServer struct {
+	syncConfigs      []*SyncConfig
+	syncConfigsMutex sync.Mutex
+	listener         net.Listener
+}
+
+// StartControlServer creates the control socket and starts serving requests in the background
+func StartControlServer(syncConfigs []*SyncConfig) (*ControlServer, error) {
+	// Check if there is a leftover socket or another devspace up already listens on it
+	_, err := os.Stat(ControlSocket)
+	if err == nil {
+		conn, err := net.DialTimeout("unix", ControlSocket, time.Second)
+		if err == nil {
+			conn.Close()
+			return nil, errors.Errorf("%s is already in use. Is another devspace up running in this project?", ControlSocket)
+		}
+
+		err = os.Remove(ControlSocket)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+
+	err = os.MkdirAll(filepath.Dir(ControlSocket), 0755)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	listener, err := net.Listen("unix", ControlSocket)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	server := &ControlServer{
+		syncConfigs: syncConfigs,
+		listener:    listener,
+	}
+
+	go server.serve()
+
+	return server, nil
+}
+
+// Stop closes the control socket
+func (c *ControlServer) Stop() {
+	c.listener.Close()
+	os.Remove(ControlSocket)
+}
+
+// SetSyncConfigs replaces the syncs the commands are applied to, e.g. after the syncs were restarted for a new pod
+func (c *ControlServer) SetSyncConfigs(syncConfigs []*SyncConfig) {
+	c.syncConfigsMutex.Lock()
+	defer c.syncConfigsMutex.Unlock()
+
+	c.syncConfigs = syncConfigs
+}
+
+func (c *ControlServer) getSyncConfigs() []*SyncConfig {
+	c.syncConfigsMutex.Lock()
+	defer c.syncConfigsMutex.Unlock()
+
+	return c.syncConfigs
+}
+
+func (c *ControlServer) serve() {
+	for {
+		conn, err := c.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		go c.handleConnection(conn)
+	}
+}
+
+func (c *ControlServer) handleConnection(conn net.Conn) {
+	defer conn.Close()
+
+	syncConfigs := c.getSyncConfigs()
+	request := &ControlRequest{}
+	response := &ControlResponse{
+		Syncs: make([]*ControlSyncStatus, 0, len(syncConfigs)),
+	}
+
+	err := json.NewDecoder(conn).Decode(request)
+	if err != nil {
+		response.Error = err.Error()
+	} else if request.Command != ControlPause && request.Command != ControlResume && request.Command != ControlFlush && request.Command != ControlStatus {
+		response.Error = "Unknown command " + request.Command
+	} else {
+		for _, syncConfig := range syncConfigs {
+			response.Syncs = append(response.Syncs, c.handleCommand(syncConfig, request.Command))
+		}
+	}
+
+	// The client may already be gone, so we don't care about errors here
+	json.NewEncoder(conn).Encode(response)
+}
+
+func (c *ControlServer) handleCommand(syncConfig *SyncConfig, command string) *ControlSyncStatus {
+	var err error
+
+	switch command {
+	case ControlPause:
+		syncConfig.Pause()
+	case ControlResume:
+		err = syncConfig.Resume()
+	case ControlFlush:
+		err = syncConfig.Flush()
+	}
+
+	status := &ControlSyncStatus{
+		Local:          syncConfig.WatchPath,
+		Container:      syncConfig.DestPath,
+		Paused:         syncConfig.IsPaused(),
+		PendingChanges: syncConfig.PendingChanges(),
+	}
+
+	if syncConfig.Pod != nil {
+		status.Pod = syncConfig.Pod.Name
+	}
+
+	if err != nil {
+		status.Error = err.Error()
+	}
+
+	return status
+}
+
+// SendControlCommand sends the given command to the control server of the running devspace up
+func SendControlCommand(command string) (*ControlResponse, error) {
+	conn, err := net.DialTimeout("unix", ControlSocket, 5*time.Second)
+	if err != nil {
+		return nil, errors.Errorf("Couldn't connect to %s. Is devspace up running with sync enabled?", ControlSocket)
+	}
+
+	defer conn.Close()
+
+	err = json.NewEncoder(conn).Encode(&ControlRequest{
+		Command: command,
+	})
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	response := &ControlResponse{}
+
+	err = json.NewDecoder(conn).Decode(response)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	if response.Error != "" {
+		return nil, errors.New(response.Error)
+	}
+
+	return response, nil
+}
@@ -0,0 +1,286 @@
+package sync
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func startTestSync(t *testing.T, local, remote string) *SyncConfig {
+	syncClient := createTestSyncClient(local, remote)
+	syncClient.errorChan = make(chan error)
+
+	err := syncClient.setup()
+	if err != nil {
+		t.Fatalf("Couldn't init test sync client: %v", err)
+	}
+
+	err = syncClient.upstream.start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = syncClient.downstream.start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	syncClient.readyChan = make(chan bool)
+
+	go syncClient.startUpstream()
+	go syncClient.startDownstream()
+
+	<-syncClient.readyChan
+
+	return syncClient
+}
+
+func TestPauseResume(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping test on windows")
+	}
+
+	remote, local, outside := initTestDirs(t)
+	defer os.RemoveAll(remote)
+	defer os.RemoveAll(local)
+	defer os.RemoveAll(outside)
+
+	syncClient := startTestSync(t, local, remote)
+	defer syncClient.Stop()
+
+	err := ioutil.WriteFile(path.Join(local, "removedWhilePaused"), []byte(fileContents), 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkFilesAndFolders(t, []checkedFileOrFolder{
+		{
+			path:                "removedWhilePaused",
+			shouldExistInLocal:  true,
+			shouldExistInRemote: true,
+		},
+	}, nil, local, remote, 10*time.Second)
+
+	syncClient.Pause()
+
+	err = ioutil.WriteFile(path.Join(local, "createdWhilePaused"), []byte(fileContents), 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.Remove(path.Join(local, "removedWhilePaused"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = ioutil.WriteFile(path.Join(remote, "createdRemoteWhilePaused"), []byte(fileContents), 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Give the watcher and the downstream enough time to pick up the changes
+	time.Sleep(4 * time.Second)
+
+	if syncClient.PendingChanges() == 0 {
+		t.Error("Expected buffered changes while paused")
+	}
+
+	_, err = os.Stat(path.Join(remote, "createdWhilePaused"))
+	if err == nil {
+		t.Error("createdWhilePaused was uploaded while the sync was paused")
+	}
+
+	_, err = os.Stat(path.Join(remote, "removedWhilePaused"))
+	if err != nil {
+		t.Error("removedWhilePaused was removed remotely while the sync was paused")
+	}
+
+	_, err = os.Stat(path.Join(local, "createdRemoteWhilePaused"))
+	if err == nil {
+		t.Error("createdRemoteWhilePaused was downloaded while the sync was paused")
+	}
+
+	err = syncClient.Flush()
+	if err == nil {
+		t.Error("Expected flush to fail while the sync is paused")
+	}
+
+	err = syncClient.Resume()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if syncClient.IsPaused() || syncClient.PendingChanges() != 0 {
+		t.Error("Sync is still paused after resume")
+	}
+
+	checkFilesAndFolders(t, []checkedFileOrFolder{
+		{
+			path:                "createdWhilePaused",
+			shouldExistInLocal:  true,
+			shouldExistInRemote: true,
+		},
+		{
+			path:                "removedWhilePaused",
+			shouldExistInLocal:  false,
+			shouldExistInRemote: false,
+		},
+		{
+			path:                "createdRemoteWhilePaused",
+			shouldExistInLocal:  true,
+			shouldExistInRemote: true,
+		},
+	}, nil, local, remote, 10*time.Second)
+}
+
+func TestControlServer(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping test on windows")
+	}
+
+	remote, local, outside := initTestDirs(t)
+	defer os.RemoveAll(remote)
+	defer os.RemoveAll(local)
+	defer os.RemoveAll(outside)
+
+	syncClient := startTestSync(t, local, remote)
+	defer syncClient.Stop()
+
+	oldControlSocket := ControlSocket
+	ControlSocket = path.Join(outside, "sync.sock")
+	defer func() { ControlSocket = oldControlSocket }()
+
+	server, err := StartControlServer([]*SyncConfig{syncClient})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer server.Stop()
+
+	// A second server must not take over the socket
+	_, err = StartControlServer([]*SyncConfig{syncClient})
+	if err == nil {
+		t.Fatal("Expected second control server to fail")
+	}
+
+	response, err := SendControlCommand(ControlPause)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(response.Syncs) != 1 || response.Syncs[0].Paused == false || response.Syncs[0].Local != local {
+		t.Fatalf("Unexpected pause response: %#+v", response.Syncs)
+	}
+
+	response, err = SendControlCommand(ControlResume)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.Syncs[0].Paused || response.Syncs[0].Error != "" {
+		t.Fatalf("Unexpected resume response: %#+v", response.Syncs[0])
+	}
+
+	err = ioutil.WriteFile(path.Join(local, "flushedFile"), []byte(fileContents), 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Wait for the watcher to pick up the change, flush then uploads it right away
+	time.Sleep(200 * time.Millisecond)
+
+	response, err = SendControlCommand(ControlFlush)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.Syncs[0].Error != "" {
+		t.Fatalf("Flush failed: %s", response.Syncs[0].Error)
+	}
+
+	data, err := ioutil.ReadFile(path.Join(remote, "flushedFile"))
+	if err != nil || string(data) != fileContents {
+		t.Errorf("flushedFile was not uploaded by flush: %v", err)
+	}
+
+	_, err = SendControlCommand("unknown")
+	if err == nil {
+		t.Error("Expected unknown command to fail")
+	}
+}
+
+func TestStartPaused(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping test on windows")
+	}
+
+	remote, local, outside := initTestDirs(t)
+	defer os.RemoveAll(remote)
+	defer os.RemoveAll(local)
+	defer os.RemoveAll(outside)
+
+	err := ioutil.WriteFile(path.Join(local, "createdBeforeStart"), []byte(fileContents), 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = ioutil.WriteFile(path.Join(remote, "createdRemoteBeforeStart"), []byte(fileContents), 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	syncClient := createTestSyncClient(local, remote)
+	syncClient.errorChan = make(chan error)
+	syncClient.Paused = true
+	defer syncClient.Stop()
+
+	err = syncClient.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if syncClient.IsPaused() == false {
+		t.Fatal("Expected the sync to be paused")
+	}
+
+	err = ioutil.WriteFile(path.Join(local, "createdWhilePaused"), []byte(fileContents), 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Give the initial sync and the watcher enough time to (wrongly) apply the changes
+	time.Sleep(2 * time.Second)
+
+	for _, fullpath := range []string{path.Join(remote, "createdBeforeStart"), path.Join(remote, "createdWhilePaused"), path.Join(local, "createdRemoteBeforeStart")} {
+		_, err = os.Stat(fullpath)
+		if err == nil {
+			t.Errorf("%s was synced while the sync was paused", fullpath)
+		}
+	}
+
+	err = syncClient.Resume()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkFilesAndFolders(t, []checkedFileOrFolder{
+		{
+			path:                "createdBeforeStart",
+			shouldExistInLocal:  true,
+			shouldExistInRemote: true,
+		},
+		{
+			path:                "createdWhilePaused",
+			shouldExistInLocal:  true,
+			shouldExistInRemote: true,
+		},
+		{
+			path:                "createdRemoteBeforeStart",
+			shouldExistInLocal:  true,
+			shouldExistInRemote: true,
+		},
+	}, nil, local, remote, 10*time.Second)
+}
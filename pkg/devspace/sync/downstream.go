@@ -9,6 +9,7 @@ import (
 	"os/exec"
 	"path"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -20,15 +21,20 @@ import (
 
 type downstream struct {
 	interrupt chan bool
+	flush     chan chan error
 	config    *SyncConfig
 
 	stdinPipe  io.WriteCloser
 	stdoutPipe io.ReadCloser
 	stderrPipe io.ReadCloser
+
+	// Transfer workers with their own exec session (only used during initial sync)
+	workers []*downstream
 }
 
 func (d *downstream) start() error {
 	d.interrupt = make(chan bool, 1)
+	d.flush = make(chan chan error)
 
 	err := d.startShell()
 	if err != nil {
@@ -100,34 +106,64 @@ func (d *downstream) mainLoop() error {
 	lastAmountChanges := 0
 
 	for {
-		removeFiles := d.cloneFileMap()
+		amountChanges := 0
 
-		// Check for changes remotely
-		createFiles, err := d.collectChanges(removeFiles)
-		if err != nil {
-			return errors.Trace(err)
-		}
+		// We don't look for remote changes while the sync is paused
+		if d.config.IsPaused() == false {
+			removeFiles := d.cloneFileMap()
 
-		amountChanges := len(createFiles) + len(removeFiles)
-
-		if lastAmountChanges > 0 && amountChanges == lastAmountChanges {
-			err = d.applyChanges(createFiles, removeFiles)
+			// Check for changes remotely
+			createFiles, err := d.collectChanges(removeFiles)
 			if err != nil {
 				return errors.Trace(err)
 			}
+
+			amountChanges = len(createFiles) + len(removeFiles)
+
+			if lastAmountChanges > 0 && amountChanges == lastAmountChanges {
+				err = d.applyChanges(createFiles, removeFiles)
+				if err != nil {
+					return errors.Trace(err)
+				}
+			}
 		}
 
 		select {
 		case <-d.interrupt:
 			return nil
+		case done := <-d.flush:
+			err := d.flushChanges()
+			done <- err
+
+			if err != nil {
+				return errors.Trace(err)
+			}
+
+			amountChanges = 0
 		case <-time.After(1300 * time.Millisecond):
 			break
 		}
 
-		lastAmountChanges = len(createFiles) + len(removeFiles)
+		lastAmountChanges = amountChanges
 	}
 }
 
+// flushChanges collects the remote changes and applies them without waiting for them to settle
+func (d *downstream) flushChanges() error {
+	removeFiles := d.cloneFileMap()
+
+	createFiles, err := d.collectChanges(removeFiles)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	if len(createFiles) == 0 && len(removeFiles) == 0 {
+		return nil
+	}
+
+	return d.applyChanges(createFiles, removeFiles)
+}
+
 func (d *downstream) cloneFileMap() map[string]*fileInformation {
 	d.config.fileIndex.fileMapMutex.Lock()
 	defer d.config.fileIndex.fileMapMutex.Unlock()
@@ -155,7 +191,7 @@ func (d *downstream) applyChanges(createFiles []*fileInformation, removeFiles ma
 
 	downloadFiles := make([]*fileInformation, 0, int(len(createFiles)/2))
 	createFolders := make([]*fileInformation, 0, int(len(createFiles)/2))
-	tempDownloadpath := ""
+	tempDownloadpaths := []string{}
 
 	// Determine folder creates and file creates and separate them
 	for _, element := range createFiles {
@@ -168,29 +204,32 @@ func (d *downstream) applyChanges(createFiles []*fileInformation, removeFiles ma
 
 	// Download files first without locking the fileMap so upstream has more time to process other changes
 	if len(downloadFiles) > 0 {
-		tempDownloadpath, err = d.downloadFiles(downloadFiles)
+		if len(d.workers) > 1 && len(downloadFiles) > 1 {
+			tempDownloadpaths, err = d.downloadFilesParallel(downloadFiles)
+		} else {
+			tempDownloadpath := ""
+			tempDownloadpath, err = d.downloadFiles(downloadFiles)
+			tempDownloadpaths = append(tempDownloadpaths, tempDownloadpath)
+		}
+
+		defer func() {
+			for _, tempDownloadpath := range tempDownloadpaths {
+				if tempDownloadpath != "" {
+					os.Remove(tempDownloadpath)
+				}
+			}
+		}()
 
 		if err != nil {
 			return errors.Trace(err)
 		}
-
-		defer os.Remove(tempDownloadpath)
 	}
 
 	d.removeFilesAndFolders(removeFiles)
 	d.createFolders(createFolders)
 
-	if len(downloadFiles) > 0 {
-		f, err := os.Open(tempDownloadpath)
-		if err != nil {
-			return errors.Trace(err)
-		}
-
-		defer f.Close()
-
-		// Untaring all downloaded files to the right location
-		// this can be a lengthy process when we downloaded a lot of files
-		err = untarAll(f, d.config.WatchPath, d.config.DestPath, d.config)
+	for _, tempDownloadpath := range tempDownloadpaths {
+		err = d.untarDownload(tempDownloadpath)
 		if err != nil {
 			return errors.Trace(err)
 		}
@@ -200,6 +239,19 @@ func (d *downstream) applyChanges(createFiles []*fileInformation, removeFiles ma
 	return nil
 }
 
+func (d *downstream) untarDownload(tempDownloadpath string) error {
+	f, err := os.Open(tempDownloadpath)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	defer f.Close()
+
+	// Untaring all downloaded files to the right location
+	// this can be a lengthy process when we downloaded a lot of files
+	return untarAll(f, d.config.WatchPath, d.config.DestPath, d.config)
+}
+
 func (d *downstream) downloadFiles(files []*fileInformation) (string, error) {
 	var buffer bytes.Buffer
 	lenFiles := len(files)
@@ -228,8 +280,8 @@ func (d *downstream) downloadFiles(files []*fileInformation) (string, error) {
 
 	// TODO: Implement timeout to prevent potential endless loop
 	cmd := "fileSize=" + strconv.Itoa(len(filenames)) + `;
-					tmpFileInput="/tmp/devspace-downstream-input";
-					tmpFileOutput="/tmp/devspace-downstream-output";
+					tmpFileInput="/tmp/devspace-downstream-input-$$";
+					tmpFileOutput="/tmp/devspace-downstream-output-$$";
 					mkdir -p /tmp;
 
 					pid=$$;
@@ -248,7 +300,7 @@ func (d *downstream) downloadFiles(files []*fileInformation) (string, error) {
 
 							sleep 0.1;
 					done;
-					tar -czf "$tmpFileOutput" -T "$tmpFileInput" 2>/dev/null;
+					tar --format=posix -czf "$tmpFileOutput" -T "$tmpFileInput" 2>/dev/null || tar -czf "$tmpFileOutput" -T "$tmpFileInput" 2>/dev/null;
 					(>&2 echo "` + StartAck + `");
 					(>&2 echo $(stat -c "%s" "$tmpFileOutput"));
 					(>&2 echo "` + EndAck + `");
@@ -331,7 +383,21 @@ func (d *downstream) removeFilesAndFolders(removeFiles map[string]*fileInformati
 		d.config.Logf("[Downstream] Remove %d files", numRemoveFiles)
 	}
 
-	for key, value := range removeFiles {
+	// Process removes in a deterministic order
+	removeKeys := make([]string, 0, numRemoveFiles)
+	for key := range removeFiles {
+		removeKeys = append(removeKeys, key)
+	}
+
+	sort.Strings(removeKeys)
+
+	for _, key := range removeKeys {
+		value := removeFiles[key]
+		if value == nil {
+			// Already removed together with its parent directory
+			continue
+		}
+
 		absFilepath := filepath.Join(d.config.WatchPath, key)
 
 		if shouldRemoveLocal(absFilepath, value, d.config) {
@@ -367,6 +433,9 @@ func (d *downstream) createFolders(createFolders []*fileInformation) {
 		d.config.Logf("[Downstream] Create %d folders", len(createFolders))
 	}
 
+	// Parent folders are always created before their children
+	sortByName(createFolders)
+
 	for _, element := range createFolders {
 		if element.IsDirectory {
 			if numCreateFolders <= 3 || d.config.verbose {
@@ -75,12 +75,14 @@ func shouldUpload(relativePath string, stat os.FileInfo, s *SyncConfig, isInitia
 
 		if isInitial {
 			// File is older locally than remote so don't update remote
-			if roundMtime(stat.ModTime()) <= s.fileIndex.fileMap[relativePath].Mtime {
+			if truncMtime(stat.ModTime()) <= s.fileIndex.fileMap[relativePath].Mtime {
 				return false
 			}
 		} else {
-			// File did not change or was changed by downstream
-			if roundMtime(stat.ModTime()) == s.fileIndex.fileMap[relativePath].Mtime && stat.Size() == s.fileIndex.fileMap[relativePath].Size {
+			// File did not change or was changed by downstream. The sub-second part is only compared if the
+			// fileMap knows it, remote entries (e.g. from the initial sync) only have whole seconds
+			fileMapNano := s.fileIndex.fileMap[relativePath].MtimeNano
+			if truncMtime(stat.ModTime()) == s.fileIndex.fileMap[relativePath].Mtime && (fileMapNano == 0 || mtimeNano(stat.ModTime()) == fileMapNano) && stat.Size() == s.fileIndex.fileMap[relativePath].Size {
 				return false
 			}
 		}
@@ -174,11 +176,11 @@ func shouldRemoveLocal(absFilepath string, fileInformation *fileInformation, s *
 			// We don't delete the file if it has changed in the map since we collected changes
 			if fileInformation.Mtime == s.fileIndex.fileMap[fileInformation.Name].Mtime && fileInformation.Size == s.fileIndex.fileMap[fileInformation.Name].Size {
 				// We don't delete the file if it has changed on the filesystem meanwhile
-				if roundMtime(stat.ModTime()) <= fileInformation.Mtime {
+				if truncMtime(stat.ModTime()) <= fileInformation.Mtime {
 					return true
 				}
 
-				s.Logf("Skip %s because stat.ModTime() %d is greater than fileInformation.Mtime %d", absFilepath, roundMtime(stat.ModTime()), fileInformation.Mtime)
+				s.Logf("Skip %s because stat.ModTime() %d is greater than fileInformation.Mtime %d", absFilepath, truncMtime(stat.ModTime()), fileInformation.Mtime)
 			} else {
 				s.Logf("Skip %s because Mtime (%d and %d) or Size (%d and %d) is unequal between fileInformation and fileMap", absFilepath, fileInformation.Mtime, s.fileIndex.fileMap[fileInformation.Name].Mtime, fileInformation.Size, s.fileIndex.fileMap[fileInformation.Name].Size)
 			}
@@ -19,9 +19,10 @@ const IsRegularFile uint64 = 0100000
 const IsSymbolicLink uint64 = 0120000
 
 type fileInformation struct {
-	Name  string // %n
-	Size  int64  // %s
-	Mtime int64  // %Y
+	Name      string // %n
+	Size      int64  // %s
+	Mtime     int64  // %Y
+	MtimeNano int64  // Sub-second part of the mtime, only known for local files
 
 	IsSymbolicLink bool // parseHex(%f) & 0120000
 	IsDirectory    bool // parseHex(%f) & 040000
@@ -39,6 +39,13 @@ type SyncConfig struct {
 	DownloadExcludePaths []string
 	UploadExcludePaths   []string
 
+	// TransferWorkers is the number of parallel exec sessions per direction used for the initial sync
+	TransferWorkers int
+
+	// Paused starts the sync paused, e.g. to keep the state of a sync that is restarted for a new pod.
+	// The initial sync is done when the sync is resumed
+	Paused bool
+
 	fileIndex *fileIndex
 
 	ignoreMatcher         gitignore.IgnoreParser
@@ -53,7 +60,15 @@ type SyncConfig struct {
 	silent  bool
 	verbose bool
 
-	stopOnce sync.Once
+	stopOnce     sync.Once
+	workersMutex sync.Mutex
+
+	pauseMutex  sync.Mutex
+	paused      bool
+	pausedPaths map[string]bool
+
+	// initialSyncResume is closed when a sync that was started paused is resumed
+	initialSyncResume chan struct{}
 
 	// Used for testing
 	testing   bool
@@ -105,9 +120,9 @@ func (s *SyncConfig) setup() error {
 		s.ExcludePaths = make([]string, 0, 2)
 	}
 
-	// We exclude the sync log to prevent an endless loop in upstream
+	// We exclude the sync log to prevent an endless loop in upstream and the control socket
 	s.fileIndex = newFileIndex()
-	s.ExcludePaths = append(s.ExcludePaths, "/.devspace/logs")
+	s.ExcludePaths = append(s.ExcludePaths, "/.devspace/logs", "/"+ControlSocket)
 
 	if syncLog == nil {
 		// Check if syncLog already exists
@@ -161,6 +176,14 @@ func (s *SyncConfig) Start() error {
 		return errors.Trace(err)
 	}
 
+	if s.Paused {
+		s.Pause()
+
+		s.pauseMutex.Lock()
+		s.initialSyncResume = make(chan struct{})
+		s.pauseMutex.Unlock()
+	}
+
 	go s.mainLoop()
 
 	return nil
@@ -207,6 +230,19 @@ func (s *SyncConfig) mainLoop() {
 	go func() {
 		defer s.Stop()
 
+		// A sync that was started paused does the initial sync when it is resumed
+		s.pauseMutex.Lock()
+		initialSyncResume := s.initialSyncResume
+		s.pauseMutex.Unlock()
+
+		if initialSyncResume != nil {
+			select {
+			case <-initialSyncResume:
+			case <-s.upstream.interrupt:
+				return
+			}
+		}
+
 		err := s.initialSync()
 		if err != nil {
 			s.Error(err)
@@ -273,8 +309,25 @@ func (s *SyncConfig) initialSync() error {
 		return errors.Trace(err)
 	}
 
+	err = s.startTransferWorkers()
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	defer s.stopTransferWorkers()
+
+	var uploadDone chan error
+
 	if len(localChanges) > 0 {
-		go s.sendChangesToUpstream(localChanges)
+		if len(s.upstream.workers) > 0 {
+			uploadDone = make(chan error, 1)
+
+			go func() {
+				uploadDone <- s.upstream.applyCreatesParallel(localChanges)
+			}()
+		} else {
+			go s.sendChangesToUpstream(localChanges)
+		}
 	}
 
 	if len(fileMapClone) > 0 {
@@ -284,11 +337,20 @@ func (s *SyncConfig) initialSync() error {
 		}
 
 		err = s.downstream.applyChanges(remoteChanges, nil)
-		if err != nil {
-			return errors.Trace(err)
+	}
+
+	// Wait for the parallel upload, because the workers are closed afterwards
+	if uploadDone != nil {
+		uploadErr := <-uploadDone
+		if err == nil {
+			err = uploadErr
 		}
 	}
 
+	if err != nil {
+		return errors.Trace(err)
+	}
+
 	return nil
 }
 
@@ -308,11 +370,12 @@ func (s *SyncConfig) diffServerClient(filepath string, sendChanges *[]*fileInfor
 		if s.uploadIgnoreMatcher.MatchesPath(relativePath) {
 			s.fileIndex.fileMapMutex.Lock()
 			// Add to file map and prevent download if local file is newer than the remote one
-			if s.fileIndex.fileMap[relativePath] != nil && s.fileIndex.fileMap[relativePath].Mtime < roundMtime(stat.ModTime()) {
+			if s.fileIndex.fileMap[relativePath] != nil && s.fileIndex.fileMap[relativePath].Mtime < truncMtime(stat.ModTime()) {
 				// Add it to the fileMap
 				s.fileIndex.fileMap[relativePath] = &fileInformation{
 					Name:        relativePath,
-					Mtime:       roundMtime(stat.ModTime()),
+					Mtime:       truncMtime(stat.ModTime()),
+					MtimeNano:   mtimeNano(stat.ModTime()),
 					Size:        stat.Size(),
 					IsDirectory: stat.IsDir(),
 				}
@@ -338,7 +401,7 @@ func (s *SyncConfig) diffServerClient(filepath string, sendChanges *[]*fileInfor
 	// Add file to upload
 	*sendChanges = append(*sendChanges, &fileInformation{
 		Name:        relativePath,
-		Mtime:       roundMtime(stat.ModTime()),
+		Mtime:       truncMtime(stat.ModTime()),
 		Size:        stat.Size(),
 		IsDirectory: false,
 	})
@@ -358,7 +421,7 @@ func (s *SyncConfig) diffDir(filepath string, stat os.FileInfo, sendChanges *[]*
 	if len(files) == 0 && relativePath != "" {
 		*sendChanges = append(*sendChanges, &fileInformation{
 			Name:        relativePath,
-			Mtime:       roundMtime(stat.ModTime()),
+			Mtime:       truncMtime(stat.ModTime()),
 			Size:        stat.Size(),
 			IsDirectory: true,
 		})
@@ -403,6 +466,11 @@ func (s *SyncConfig) sendChangesToUpstream(changes []*fileInformation) {
 // Stop stops the sync process
 func (s *SyncConfig) Stop() {
 	s.stopOnce.Do(func() {
+		// Workers are removed by initialSync itself, we only close their sessions here
+		s.workersMutex.Lock()
+		s.closeTransferWorkers()
+		s.workersMutex.Unlock()
+
 		if s.upstream != nil && s.upstream.interrupt != nil {
 			close(s.upstream.interrupt)
 
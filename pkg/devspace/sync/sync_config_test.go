@@ -3,6 +3,7 @@ package sync
 import (
 	"io/ioutil"
 	"os"
+	"os/exec"
 	"path"
 	"path/filepath"
 	"regexp"
@@ -104,6 +105,302 @@ func TestInitialSync(t *testing.T) {
 	checkFilesAndFolders(t, filesToCheck, foldersToCheck, local, remote, 10*time.Second)
 }
 
+func TestInitialSyncParallel(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping test on windows")
+	}
+
+	remote, local, outside := initTestDirs(t)
+	defer os.RemoveAll(remote)
+	defer os.RemoveAll(local)
+	defer os.RemoveAll(outside)
+
+	filesToCheck, foldersToCheck := makeBasicTestCases()
+
+	syncClient := createTestSyncClient(local, remote)
+	syncClient.TransferWorkers = 3
+	defer syncClient.Stop()
+
+	syncClient.errorChan = make(chan error)
+	setExcludePaths(syncClient, append(filesToCheck, foldersToCheck...))
+
+	err := syncClient.setup()
+	if err != nil {
+		t.Errorf("Couldn't init test sync client: %v", err)
+		return
+	}
+
+	err = syncClient.upstream.start()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = syncClient.downstream.start()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = createTestFilesAndFolders(local, remote, outside, filesToCheck, foldersToCheck)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	go syncClient.startUpstream()
+
+	err = syncClient.initialSync()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if len(syncClient.upstream.workers) != 0 || len(syncClient.downstream.workers) != 0 {
+		t.Error("Transfer workers were not stopped after initial sync")
+	}
+
+	checkFilesAndFolders(t, filesToCheck, foldersToCheck, local, remote, 10*time.Second)
+}
+
+func TestSplitBySize(t *testing.T) {
+	files := []*fileInformation{
+		{Name: "/a", Size: 10},
+		{Name: "/b", Size: 70},
+		{Name: "/c", Size: 20},
+		{Name: "/d", Size: 30},
+		{Name: "/e", Size: 30},
+	}
+
+	batches := splitBySize(files, 2)
+	if len(batches) != 2 {
+		t.Fatalf("Expected 2 batches, got %d", len(batches))
+	}
+
+	expected := [][]string{{"/a", "/b"}, {"/c", "/d", "/e"}}
+	for i, batch := range batches {
+		names := make([]string, 0, len(batch))
+		for _, file := range batch {
+			names = append(names, file.Name)
+		}
+
+		if strings.Join(names, ",") != strings.Join(expected[i], ",") {
+			t.Errorf("Unexpected batch %d: got %v, expected %v", i, names, expected[i])
+		}
+	}
+
+	// The input order must not change the result
+	reversed := []*fileInformation{files[4], files[3], files[2], files[1], files[0]}
+	reversedBatches := splitBySize(reversed, 2)
+
+	for i := range batches {
+		for j := range batches[i] {
+			if batches[i][j].Name != reversedBatches[i][j].Name {
+				t.Fatalf("Split depends on input order: %s != %s", batches[i][j].Name, reversedBatches[i][j].Name)
+			}
+		}
+	}
+
+	if len(splitBySize(files, 10)) != len(files) {
+		t.Error("Expected at most one batch per file")
+	}
+}
+
+func TestSplitByCount(t *testing.T) {
+	files := []*fileInformation{
+		{Name: "/e"},
+		{Name: "/b"},
+		{Name: "/d"},
+		{Name: "/a"},
+		{Name: "/c"},
+	}
+
+	batches := splitByCount(files, 2)
+
+	expected := [][]string{{"/a", "/b"}, {"/c", "/d"}, {"/e"}}
+	if len(batches) != len(expected) {
+		t.Fatalf("Expected %d batches, got %d", len(expected), len(batches))
+	}
+
+	for i, batch := range batches {
+		names := make([]string, 0, len(batch))
+		for _, file := range batch {
+			names = append(names, file.Name)
+		}
+
+		if strings.Join(names, ",") != strings.Join(expected[i], ",") {
+			t.Errorf("Unexpected batch %d: got %v, expected %v", i, names, expected[i])
+		}
+	}
+
+	if files[0].Name != "/e" {
+		t.Error("Expected the input to stay unchanged")
+	}
+	if len(splitByCount([]*fileInformation{}, 2)) != 0 {
+		t.Error("Expected no batches without files")
+	}
+}
+
+func TestModeAndMtimeFidelity(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping test on windows")
+	}
+
+	// Only GNU tar keeps sub-second mtimes from pax headers, other implementations fall back to whole seconds
+	tarVersion, _ := exec.Command("tar", "--version").Output()
+	preciseMtimes := strings.Contains(string(tarVersion), "GNU tar")
+
+	testCases := []struct {
+		path         string
+		mode         os.FileMode
+		mtime        time.Time
+		editLocation int
+	}{
+		{
+			path:         "executableLocal",
+			mode:         0755,
+			mtime:        time.Unix(1500000000, 123456789),
+			editLocation: editInLocal,
+		},
+		{
+			path:         "regularLocal",
+			mode:         0644,
+			mtime:        time.Unix(1500000001, 987654321),
+			editLocation: editInLocal,
+		},
+		{
+			path:         "privateLocal",
+			mode:         0600,
+			mtime:        time.Unix(1500000002, 0),
+			editLocation: editInLocal,
+		},
+		{
+			path:         "executableRemote",
+			mode:         0755,
+			mtime:        time.Unix(1500000003, 500000000),
+			editLocation: editInRemote,
+		},
+		{
+			path:         "regularRemote",
+			mode:         0644,
+			mtime:        time.Unix(1500000004, 1000),
+			editLocation: editInRemote,
+		},
+	}
+
+	remote, local, outside := initTestDirs(t)
+	defer os.RemoveAll(remote)
+	defer os.RemoveAll(local)
+	defer os.RemoveAll(outside)
+
+	for _, testCase := range testCases {
+		parentDir, _ := getParentDir(local, remote, outside, testCase.editLocation)
+		filePath := path.Join(parentDir, testCase.path)
+
+		err := ioutil.WriteFile(filePath, []byte(fileContents), 0666)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = os.Chmod(filePath, testCase.mode)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = os.Chtimes(filePath, testCase.mtime, testCase.mtime)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	syncClient := createTestSyncClient(local, remote)
+	defer syncClient.Stop()
+
+	syncClient.errorChan = make(chan error)
+
+	err := syncClient.setup()
+	if err != nil {
+		t.Fatalf("Couldn't init test sync client: %v", err)
+	}
+
+	err = syncClient.upstream.start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = syncClient.downstream.start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go syncClient.startUpstream()
+
+	err = syncClient.initialSync()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, testCase := range testCases {
+		targetDir := remote
+		if testCase.editLocation == editInRemote {
+			targetDir = local
+		}
+
+		targetPath := path.Join(targetDir, testCase.path)
+		expectedMtime := testCase.mtime
+
+		if preciseMtimes == false {
+			expectedMtime = time.Unix(testCase.mtime.Unix(), 0)
+		}
+
+		var stat os.FileInfo
+		beginTimeStamp := time.Now()
+
+		for time.Since(beginTimeStamp) < 10*time.Second {
+			stat, err = os.Stat(targetPath)
+			if err == nil && stat.Size() == int64(len(fileContents)) && stat.ModTime().Equal(expectedMtime) {
+				break
+			}
+
+			time.Sleep(100 * time.Millisecond)
+		}
+
+		if err != nil {
+			t.Errorf("%s was not synced: %v", targetPath, err)
+			continue
+		}
+
+		if stat.Mode().Perm() != testCase.mode {
+			t.Errorf("Wrong mode for %s: got %v, expected %v", targetPath, stat.Mode().Perm(), testCase.mode)
+		}
+
+		if stat.ModTime().Equal(expectedMtime) == false {
+			t.Errorf("Wrong mtime for %s: got %v, expected %v", targetPath, stat.ModTime().UnixNano(), expectedMtime.UnixNano())
+		}
+	}
+}
+
+func TestGetUploadMode(t *testing.T) {
+	testCases := []struct {
+		remoteMode int64
+		localMode  os.FileMode
+		expected   int64
+	}{
+		{remoteMode: 0644, localMode: 0755, expected: 0755},
+		{remoteMode: 0755, localMode: 0644, expected: 0644},
+		{remoteMode: 0600, localMode: 0700, expected: 0700},
+		{remoteMode: 0640, localMode: 0755, expected: 0751},
+		{remoteMode: 0644, localMode: 0600, expected: 0644},
+	}
+
+	for _, testCase := range testCases {
+		mode := getUploadMode(testCase.remoteMode, testCase.localMode)
+		if mode != testCase.expected {
+			t.Errorf("getUploadMode(%o, %o): got %o, expected %o", testCase.remoteMode, testCase.localMode, mode, testCase.expected)
+		}
+	}
+}
+
 func TestNormalSync(t *testing.T) {
 	if runtime.GOOS == "windows" {
 		t.Skip("Skipping test on windows")
@@ -611,3 +908,109 @@ func TestRemoveDirInFileMap(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestUnchangedFileAfterInitialSyncNotUploaded(t *testing.T) {
+	local, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(local)
+
+	filePath := filepath.Join(local, "file")
+	err = ioutil.WriteFile(filePath, []byte("content"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mtime := time.Unix(1500000000, 123456789)
+	err = os.Chtimes(filePath, mtime, mtime)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stat, err := os.Stat(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sync := &SyncConfig{
+		fileIndex: newFileIndex(),
+	}
+
+	// The initial sync fills the fileMap from the remote side, which only knows whole seconds
+	sync.fileIndex.fileMap["/file"] = &fileInformation{
+		Name:  "/file",
+		Size:  stat.Size(),
+		Mtime: truncMtime(mtime),
+	}
+
+	if shouldUpload("/file", stat, sync, false) {
+		t.Fatal("Expected unchanged file not to be uploaded")
+	}
+
+	// A local entry knows the sub-second part, so a change within the same second is uploaded
+	sync.fileIndex.fileMap["/file"].MtimeNano = 1000
+	if shouldUpload("/file", stat, sync, false) == false {
+		t.Fatal("Expected file changed within the same second to be uploaded")
+	}
+}
+
+func TestMixedPrecisionFileMapNotUploaded(t *testing.T) {
+	local, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(local)
+
+	sync := &SyncConfig{
+		fileIndex: newFileIndex(),
+	}
+
+	// The sub-second parts are close to the next second, so rounding instead of truncating would change the seconds
+	mtimes := map[string]time.Time{
+		"/old": time.Unix(1500000000, 900000000),
+		"/new": time.Unix(1500000000, 987654321),
+	}
+
+	stats := map[string]os.FileInfo{}
+	for name, mtime := range mtimes {
+		filePath := filepath.Join(local, name)
+
+		err = ioutil.WriteFile(filePath, []byte("content"), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = os.Chtimes(filePath, mtime, mtime)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		stats[name], err = os.Stat(filePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Old entries only have whole seconds, new entries also have the sub-second part (e.g. from a PAX header)
+	sync.fileIndex.fileMap["/old"] = &fileInformation{
+		Name:  "/old",
+		Size:  stats["/old"].Size(),
+		Mtime: 1500000000,
+	}
+	sync.fileIndex.fileMap["/new"] = &fileInformation{
+		Name:      "/new",
+		Size:      stats["/new"].Size(),
+		Mtime:     1500000000,
+		MtimeNano: 987654321,
+	}
+
+	for name, stat := range stats {
+		if shouldUpload(name, stat, sync, true) {
+			t.Errorf("Expected unchanged file %s not to be uploaded by the initial sync", name)
+		}
+		if shouldUpload(name, stat, sync, false) {
+			t.Errorf("Expected unchanged file %s not to be uploaded", name)
+		}
+	}
+}
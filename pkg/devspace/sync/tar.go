@@ -62,11 +62,12 @@ func untarNext(tarReader *tar.Reader, destPath, prefix string, config *SyncConfi
 	stat, err := os.Stat(outFileName)
 
 	if err == nil {
-		if roundMtime(stat.ModTime()) > header.FileInfo().ModTime().Unix() {
+		if truncMtime(stat.ModTime()) > header.FileInfo().ModTime().Unix() {
 			// Update filemap otherwise we download and download again
 			config.fileIndex.fileMap[relativePath] = &fileInformation{
 				Name:        relativePath,
-				Mtime:       roundMtime(stat.ModTime()),
+				Mtime:       truncMtime(stat.ModTime()),
+				MtimeNano:   mtimeNano(stat.ModTime()),
 				Size:        stat.Size(),
 				IsDirectory: stat.IsDir(),
 			}
@@ -123,9 +124,12 @@ func untarNext(tarReader *tar.Reader, destPath, prefix string, config *SyncConfi
 		// Set owner & group correctly
 		// TODO: Enable this on supported platforms
 		// _ = os.Chown(outFileName, stat.Sys().(*syscall.Stat).Uid, stat.Sys().(*syscall.Stat_t).Gid)
+	} else {
+		// New files get the remote permissions, so executables stay executable
+		_ = os.Chmod(outFileName, header.FileInfo().Mode().Perm())
 	}
 
-	// Set mod time correctly
+	// Set mod time correctly (with sub-second precision if the remote tar supports pax headers)
 	err = os.Chtimes(outFileName, time.Now(), header.FileInfo().ModTime())
 
 	if err != nil {
@@ -133,13 +137,21 @@ func untarNext(tarReader *tar.Reader, destPath, prefix string, config *SyncConfi
 	}
 
 	// Update fileMap so that upstream does not upload the file
-	config.fileIndex.fileMap[relativePath] = &fileInformation{
+	downloadedFile := &fileInformation{
 		Name:        relativePath,
 		Mtime:       header.FileInfo().ModTime().Unix(),
+		MtimeNano:   mtimeNano(header.FileInfo().ModTime()),
 		Size:        header.FileInfo().Size(),
 		IsDirectory: false,
 	}
 
+	// The local filesystem might store the mtime less precisely, so we take the sub-second part from there
+	if newStat, err := os.Stat(outFileName); err == nil {
+		downloadedFile.MtimeNano = mtimeNano(newStat.ModTime())
+	}
+
+	config.fileIndex.fileMap[relativePath] = downloadedFile
+
 	return true, nil
 }
 
@@ -248,9 +260,10 @@ func tarFolder(basePath string, fileInformation *fileInformation, writtenFiles m
 		// Case empty directory
 		hdr, _ := tar.FileInfoHeader(stat, filepath)
 		hdr.Name = fileInformation.Name
+		hdr.Format = tar.FormatPAX
 
 		config.fileIndex.fileMapMutex.Lock()
-		if config.fileIndex.fileMap[fileInformation.Name] != nil {
+		if config.fileIndex.fileMap[fileInformation.Name] != nil && fileInformation.RemoteMode != 0 {
 			hdr.Mode = fileInformation.RemoteMode
 			hdr.Uid = fileInformation.RemoteUID
 			hdr.Gid = fileInformation.RemoteGID
@@ -290,9 +303,12 @@ func tarFile(basePath string, fileInformation *fileInformation, writtenFiles map
 	}
 	hdr.Name = fileInformation.Name
 
+	// Pax headers keep the sub-second part of the mtime
+	hdr.Format = tar.FormatPAX
+
 	config.fileIndex.fileMapMutex.Lock()
-	if config.fileIndex.fileMap[fileInformation.Name] != nil {
-		hdr.Mode = fileInformation.RemoteMode
+	if config.fileIndex.fileMap[fileInformation.Name] != nil && fileInformation.RemoteMode != 0 {
+		hdr.Mode = getUploadMode(fileInformation.RemoteMode, stat.Mode())
 		hdr.Uid = fileInformation.RemoteUID
 		hdr.Gid = fileInformation.RemoteGID
 	}
@@ -317,7 +333,8 @@ func createFileInformationFromStat(relativePath string, stat os.FileInfo, config
 	fileInformation := &fileInformation{
 		Name:        relativePath,
 		Size:        stat.Size(),
-		Mtime:       roundMtime(stat.ModTime()),
+		Mtime:       truncMtime(stat.ModTime()),
+		MtimeNano:   mtimeNano(stat.ModTime()),
 		IsDirectory: stat.IsDir(),
 	}
 
@@ -329,3 +346,9 @@ func createFileInformationFromStat(relativePath string, stat os.FileInfo, config
 
 	return fileInformation
 }
+
+// getUploadMode keeps the remote permissions of an existing file, but always carries over the
+// executable bits of the local file
+func getUploadMode(remoteMode int64, localMode os.FileMode) int64 {
+	return (remoteMode &^ 0111) | int64(localMode.Perm()&0111)
+}
@@ -0,0 +1,264 @@
+package sync
+
+import (
+	"io"
+	"sort"
+
+	"github.com/juju/errors"
+)
+
+// startTransferWorkers opens one additional exec session per direction for every configured
+// transfer worker. The workers are only used during the initial sync
+func (s *SyncConfig) startTransferWorkers() error {
+	if s.TransferWorkers <= 1 {
+		return nil
+	}
+
+	s.workersMutex.Lock()
+	defer s.workersMutex.Unlock()
+
+	for i := 0; i < s.TransferWorkers; i++ {
+		uploadWorker := &upstream{
+			config:   s,
+			isWorker: true,
+		}
+
+		err := uploadWorker.startShell()
+		if err != nil {
+			s.closeTransferWorkers()
+			s.upstream.workers, s.downstream.workers = nil, nil
+			return errors.Trace(err)
+		}
+
+		s.upstream.workers = append(s.upstream.workers, uploadWorker)
+
+		downloadWorker := &downstream{
+			config: s,
+		}
+
+		err = downloadWorker.startShell()
+		if err != nil {
+			s.closeTransferWorkers()
+			s.upstream.workers, s.downstream.workers = nil, nil
+			return errors.Trace(err)
+		}
+
+		s.downstream.workers = append(s.downstream.workers, downloadWorker)
+	}
+
+	s.Logf("[Sync] Started %d transfer workers", s.TransferWorkers)
+	return nil
+}
+
+// stopTransferWorkers closes the exec sessions of all transfer workers and removes them
+func (s *SyncConfig) stopTransferWorkers() {
+	s.workersMutex.Lock()
+	defer s.workersMutex.Unlock()
+
+	s.closeTransferWorkers()
+
+	if s.upstream != nil {
+		s.upstream.workers = nil
+	}
+	if s.downstream != nil {
+		s.downstream.workers = nil
+	}
+}
+
+// Function assumes that workersMutex is locked
+func (s *SyncConfig) closeTransferWorkers() {
+	if s.upstream != nil {
+		for _, worker := range s.upstream.workers {
+			closeShell(worker.stdinPipe, worker.stdoutPipe, worker.stderrPipe)
+		}
+	}
+
+	if s.downstream != nil {
+		for _, worker := range s.downstream.workers {
+			closeShell(worker.stdinPipe, worker.stdoutPipe, worker.stderrPipe)
+		}
+	}
+}
+
+func closeShell(stdinPipe io.WriteCloser, stdoutPipe, stderrPipe io.ReadCloser) {
+	if stdinPipe != nil {
+		stdinPipe.Write([]byte("exit\n"))
+		stdinPipe.Close()
+	}
+
+	if stdoutPipe != nil {
+		stdoutPipe.Close()
+	}
+
+	if stderrPipe != nil {
+		stderrPipe.Close()
+	}
+}
+
+// applyCreatesParallel uploads the given changes through the transfer workers. Empty directories
+// are created first in a single, name-sorted batch. The files are then split into batches of at most
+// initialUpstreamBatchSize files, which the workers take one after another until all are uploaded
+func (u *upstream) applyCreatesParallel(changes []*fileInformation) error {
+	directories := make([]*fileInformation, 0, 16)
+	files := make([]*fileInformation, 0, len(changes))
+
+	u.config.fileIndex.fileMapMutex.Lock()
+
+	for _, change := range changes {
+		if u.config.fileIndex.fileMap[change.Name] != nil && change.Mtime <= u.config.fileIndex.fileMap[change.Name].Mtime {
+			continue
+		}
+
+		if change.IsDirectory {
+			directories = append(directories, change)
+		} else {
+			files = append(files, change)
+		}
+	}
+
+	u.config.fileIndex.fileMapMutex.Unlock()
+
+	if len(directories) > 0 {
+		sortByName(directories)
+
+		err := u.workers[0].applyCreates(directories)
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+
+	batches := splitByCount(files, initialUpstreamBatchSize)
+	batchChan := make(chan []*fileInformation, len(batches))
+	errorChan := make(chan error, len(u.workers))
+
+	for _, batch := range batches {
+		batchChan <- batch
+	}
+
+	close(batchChan)
+
+	for _, worker := range u.workers {
+		go func(worker *upstream) {
+			for batch := range batchChan {
+				err := worker.applyCreates(batch)
+				if err != nil {
+					errorChan <- err
+					return
+				}
+			}
+
+			errorChan <- nil
+		}(worker)
+	}
+
+	var err error
+
+	for range u.workers {
+		if workerErr := <-errorChan; workerErr != nil && err == nil {
+			err = workerErr
+		}
+	}
+
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	u.config.Logf("[Upstream] Successfully processed %d change(s)", len(directories)+len(files))
+	return nil
+}
+
+// downloadFilesParallel downloads the given files through the transfer workers and
+// returns the paths of the downloaded archives in worker order
+func (d *downstream) downloadFilesParallel(files []*fileInformation) ([]string, error) {
+	batches := splitBySize(files, len(d.workers))
+	tempDownloadpaths := make([]string, len(batches))
+	errorChan := make(chan error, len(batches))
+
+	for index, batch := range batches {
+		go func(index int, worker *downstream, batch []*fileInformation) {
+			tempDownloadpath, err := worker.downloadFiles(batch)
+
+			tempDownloadpaths[index] = tempDownloadpath
+			errorChan <- err
+		}(index, d.workers[index], batch)
+	}
+
+	var err error
+
+	for range batches {
+		if batchErr := <-errorChan; batchErr != nil && err == nil {
+			err = batchErr
+		}
+	}
+
+	return tempDownloadpaths, err
+}
+
+// splitBySize distributes the files over at most the given amount of batches, so that all batches have
+// about the same size. The result does only depend on the input, not on the order of the input
+func splitBySize(files []*fileInformation, amount int) [][]*fileInformation {
+	if amount > len(files) {
+		amount = len(files)
+	}
+	if amount <= 0 {
+		return [][]*fileInformation{}
+	}
+
+	sorted := make([]*fileInformation, len(files))
+	copy(sorted, files)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Size != sorted[j].Size {
+			return sorted[i].Size > sorted[j].Size
+		}
+
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	batches := make([][]*fileInformation, amount)
+	batchSizes := make([]int64, amount)
+
+	// Always put the next largest file into the currently smallest batch
+	for _, file := range sorted {
+		smallest := 0
+
+		for i := 1; i < amount; i++ {
+			if batchSizes[i] < batchSizes[smallest] {
+				smallest = i
+			}
+		}
+
+		batches[smallest] = append(batches[smallest], file)
+		batchSizes[smallest] += file.Size
+	}
+
+	for _, batch := range batches {
+		sortByName(batch)
+	}
+
+	return batches
+}
+
+// splitByCount splits the name-sorted files into batches of at most the given amount of files
+func splitByCount(files []*fileInformation, amount int) [][]*fileInformation {
+	sorted := make([]*fileInformation, len(files))
+	copy(sorted, files)
+	sortByName(sorted)
+
+	batches := make([][]*fileInformation, 0, (len(sorted)+amount-1)/amount)
+	for len(sorted) > amount {
+		batches = append(batches, sorted[:amount])
+		sorted = sorted[amount:]
+	}
+	if len(sorted) > 0 {
+		batches = append(batches, sorted)
+	}
+
+	return batches
+}
+
+func sortByName(files []*fileInformation) {
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].Name < files[j].Name
+	})
+}
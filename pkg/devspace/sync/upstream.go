@@ -18,16 +18,24 @@ import (
 type upstream struct {
 	events    chan notify.EventInfo
 	interrupt chan bool
+	flush     chan chan error
+	resume    chan chan error
 	config    *SyncConfig
 
 	stdinPipe  io.WriteCloser
 	stdoutPipe io.ReadCloser
 	stderrPipe io.ReadCloser
+
+	// Transfer workers with their own exec session (only used during initial sync)
+	workers  []*upstream
+	isWorker bool
 }
 
 func (u *upstream) start() error {
 	u.events = make(chan notify.EventInfo, 6000) // High buffer size so we don't miss any fsevents if there are a lot of changes
 	u.interrupt = make(chan bool, 1)
+	u.flush = make(chan chan error)
+	u.resume = make(chan chan error)
 
 	err := u.startShell()
 
@@ -89,6 +97,7 @@ func (u *upstream) startShell() error {
 func (u *upstream) mainLoop() error {
 	for {
 		var changes []*fileInformation
+		var flushed []chan error
 
 		changeAmount := 0
 
@@ -96,27 +105,28 @@ func (u *upstream) mainLoop() error {
 			select {
 			case <-u.interrupt:
 				return nil
+			case done := <-u.flush:
+				flushed = append(flushed, done)
+				changes = append(changes, u.getfileInformationFromEvent(u.pendingEvents())...)
+			case done := <-u.resume:
+				flushed = append(flushed, done)
+				changes = append(changes, u.getfileInformationFromEvent(u.pendingEvents())...)
+				changes = append(changes, u.reconcile()...)
 			case event := <-u.events:
 				events := make([]notify.EventInfo, 0, 10)
 				events = append(events, event)
-
-				// We need this loop to catch up if we got a lot of change events
-				for eventsLeft := true; eventsLeft == true; {
-					select {
-					case event := <-u.events:
-						events = append(events, event)
-						break
-					default:
-						eventsLeft = false
-						break
-					}
-				}
+				events = append(events, u.pendingEvents()...)
 
 				changes = append(changes, u.getfileInformationFromEvent(events)...)
 			case <-time.After(time.Millisecond * 600):
 				break
 			}
 
+			// Flushed changes are applied right away
+			if len(flushed) > 0 {
+				break
+			}
+
 			// We gather changes till there are no more changes for 1 second
 			if changeAmount == len(changes) && changeAmount > 0 {
 				break
@@ -125,7 +135,14 @@ func (u *upstream) mainLoop() error {
 			changeAmount = len(changes)
 		}
 
-		err := u.applyChanges(changes)
+		var err error
+		if len(changes) > 0 {
+			err = u.applyChanges(changes)
+		}
+
+		for _, done := range flushed {
+			done <- err
+		}
 
 		if err != nil {
 			return err
@@ -133,7 +150,31 @@ func (u *upstream) mainLoop() error {
 	}
 }
 
+// pendingEvents returns all events that are currently waiting in the events channel
+func (u *upstream) pendingEvents() []notify.EventInfo {
+	events := make([]notify.EventInfo, 0, 10)
+
+	// We need this loop to catch up if we got a lot of change events
+	for {
+		select {
+		case event := <-u.events:
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
 func (u *upstream) getfileInformationFromEvent(events []notify.EventInfo) []*fileInformation {
+	if len(events) == 0 {
+		return nil
+	}
+
+	// While the sync is paused we only remember which paths changed
+	if u.config.bufferPaths(u.eventPaths(events)) {
+		return nil
+	}
+
 	u.config.fileIndex.fileMapMutex.Lock()
 	defer u.config.fileIndex.fileMapMutex.Unlock()
 
@@ -171,11 +212,12 @@ func evaluateChange(s *SyncConfig, fileMap map[string]*fileInformation, relative
 		if s.uploadIgnoreMatcher != nil {
 			if s.uploadIgnoreMatcher.MatchesPath(relativePath) {
 				// Add to file map and prevent download if local file is newer than the remote one
-				if s.fileIndex.fileMap[relativePath] != nil && s.fileIndex.fileMap[relativePath].Mtime < roundMtime(stat.ModTime()) {
+				if s.fileIndex.fileMap[relativePath] != nil && s.fileIndex.fileMap[relativePath].Mtime < truncMtime(stat.ModTime()) {
 					// Add it to the fileMap
 					s.fileIndex.fileMap[relativePath] = &fileInformation{
 						Name:        relativePath,
-						Mtime:       roundMtime(stat.ModTime()),
+						Mtime:       truncMtime(stat.ModTime()),
+						MtimeNano:   mtimeNano(stat.ModTime()),
 						Size:        stat.Size(),
 						IsDirectory: stat.IsDir(),
 					}
@@ -189,7 +231,7 @@ func evaluateChange(s *SyncConfig, fileMap map[string]*fileInformation, relative
 			// New Create Task
 			return &fileInformation{
 				Name:        relativePath,
-				Mtime:       roundMtime(stat.ModTime()),
+				Mtime:       truncMtime(stat.ModTime()),
 				Size:        stat.Size(),
 				IsDirectory: stat.IsDir(),
 			}
@@ -284,15 +326,19 @@ func (u *upstream) applyCreates(files []*fileInformation) error {
 }
 
 func (u *upstream) uploadArchive(file *os.File, fileSize string, writtenFiles map[string]*fileInformation) error {
-	u.config.fileIndex.fileMapMutex.Lock()
-	defer u.config.fileIndex.fileMapMutex.Unlock()
+	// Transfer workers only lock the fileMap while updating it, otherwise they would wait for each other
+	if u.isWorker == false {
+		u.config.fileIndex.fileMapMutex.Lock()
+		defer u.config.fileIndex.fileMapMutex.Unlock()
+	}
+
 	defer file.Close()
 
 	u.config.Logf("[Upstream] Upload %d create changes (size %s)", len(writtenFiles), fileSize)
 
 	// TODO: Implement timeout to prevent endless loop
 	cmd := "fileSize=" + fileSize + `;
-					tmpFile="/tmp/devspace-upstream";
+					tmpFile="/tmp/devspace-upstream-$$";
 					mkdir -p /tmp;
 					mkdir -p '` + u.config.DestPath + `';
 
@@ -351,6 +397,11 @@ func (u *upstream) uploadArchive(file *os.File, fileSize string, writtenFiles ma
 	}
 
 	// Update sync filemap
+	if u.isWorker {
+		u.config.fileIndex.fileMapMutex.Lock()
+		defer u.config.fileIndex.fileMapMutex.Unlock()
+	}
+
 	for _, element := range writtenFiles {
 		u.config.fileIndex.CreateDirInFileMap(path.Dir(element.Name))
 		u.config.fileIndex.fileMap[element.Name] = element
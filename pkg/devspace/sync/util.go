@@ -83,9 +83,15 @@ func copyToContainerTestable(Kubectl *kubernetes.Clientset, Pod *k8sv1.Pod, Cont
 	return nil
 }
 
-// We need this function because tar rounds the mtime on the server as well
-func roundMtime(mtime time.Time) int64 {
-	return mtime.Round(time.Second).Unix()
+// We need this function because stat on the server only reports whole seconds. Mtimes are truncated
+// like stat does, so that local and remote mtimes of the same file are equal. The sub-second part of
+// local mtimes is tracked separately in fileInformation.MtimeNano
+func truncMtime(mtime time.Time) int64 {
+	return mtime.Unix()
+}
+
+func mtimeNano(mtime time.Time) int64 {
+	return int64(mtime.Nanosecond())
 }
 
 func getRelativeFromFullPath(fullpath string, prefix string) string {
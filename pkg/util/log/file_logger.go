@@ -175,6 +175,12 @@ func (f *fileLogger) WithKey(key string, obj interface{}) *LoggerEntry {
 	}
 }
 
+// StartWait is a no-op, because wait messages are only shown in the terminal
+func (f *fileLogger) StartWait(message string) {}
+
+// StopWait is a no-op, because wait messages are only shown in the terminal
+func (f *fileLogger) StopWait() {}
+
 func (f *fileLogger) SetLevel(level logrus.Level) {
 	f.logger.SetLevel(level)
 }
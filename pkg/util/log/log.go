@@ -1,6 +1,8 @@
 package log
 
 import (
+	"io"
+	"os"
 	"strings"
 
 	"github.com/sirupsen/logrus"
@@ -134,6 +136,19 @@ func StartFileLogging() {
 	OverrideRuntimeErrorHandler()
 }
 
+// SetOutput changes the stream of all messages that are written to stdout by default, e.g. to keep
+// stdout free for machine readable output. Errors are still written to stderr
+func SetOutput(stream io.Writer) {
+	stdoutLog.logMutex.Lock()
+	defer stdoutLog.logMutex.Unlock()
+
+	for _, fnInformation := range fnTypeInformationMap {
+		if fnInformation.stream != os.Stderr {
+			fnInformation.stream = stream
+		}
+	}
+}
+
 // GetInstance returns the Logger instance
 func GetInstance() Logger {
 	return stdoutLog
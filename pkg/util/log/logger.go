@@ -36,6 +36,9 @@ type Logger interface {
 	Print(level logrus.Level, args ...interface{})
 	Printf(level logrus.Level, format string, args ...interface{})
 
+	StartWait(message string)
+	StopWait()
+
 	Write(message []byte) (int, error)
 	SetLevel(level logrus.Level)
 
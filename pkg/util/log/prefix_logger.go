@@ -0,0 +1,172 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// prefixLogger prefixes every message and output line, so that the output of
+// concurrent tasks (e.g. image builds) can be told apart
+type prefixLogger struct {
+	prefix string
+	logger Logger
+
+	writeMutex sync.Mutex
+	buffer     bytes.Buffer
+}
+
+// NewPrefixLogger creates a logger that writes every message with the given prefix to logger
+func NewPrefixLogger(prefix string, logger Logger) Logger {
+	return &prefixLogger{
+		prefix: prefix,
+		logger: logger,
+	}
+}
+
+func (p *prefixLogger) prefixArgs(args []interface{}) []interface{} {
+	return []interface{}{p.prefix + strings.TrimSuffix(fmt.Sprintln(args...), "\n")}
+}
+
+func (p *prefixLogger) Debug(args ...interface{}) {
+	p.logger.Debug(p.prefixArgs(args)...)
+}
+
+func (p *prefixLogger) Debugf(format string, args ...interface{}) {
+	p.logger.Debugf(p.prefix+format, args...)
+}
+
+func (p *prefixLogger) Info(args ...interface{}) {
+	p.logger.Info(p.prefixArgs(args)...)
+}
+
+func (p *prefixLogger) Infof(format string, args ...interface{}) {
+	p.logger.Infof(p.prefix+format, args...)
+}
+
+func (p *prefixLogger) Warn(args ...interface{}) {
+	p.logger.Warn(p.prefixArgs(args)...)
+}
+
+func (p *prefixLogger) Warnf(format string, args ...interface{}) {
+	p.logger.Warnf(p.prefix+format, args...)
+}
+
+func (p *prefixLogger) Error(args ...interface{}) {
+	p.logger.Error(p.prefixArgs(args)...)
+}
+
+func (p *prefixLogger) Errorf(format string, args ...interface{}) {
+	p.logger.Errorf(p.prefix+format, args...)
+}
+
+func (p *prefixLogger) Fatal(args ...interface{}) {
+	p.logger.Fatal(p.prefixArgs(args)...)
+}
+
+func (p *prefixLogger) Fatalf(format string, args ...interface{}) {
+	p.logger.Fatalf(p.prefix+format, args...)
+}
+
+func (p *prefixLogger) Panic(args ...interface{}) {
+	p.logger.Panic(p.prefixArgs(args)...)
+}
+
+func (p *prefixLogger) Panicf(format string, args ...interface{}) {
+	p.logger.Panicf(p.prefix+format, args...)
+}
+
+func (p *prefixLogger) Done(args ...interface{}) {
+	p.logger.Done(p.prefixArgs(args)...)
+}
+
+func (p *prefixLogger) Donef(format string, args ...interface{}) {
+	p.logger.Donef(p.prefix+format, args...)
+}
+
+func (p *prefixLogger) Fail(args ...interface{}) {
+	p.logger.Fail(p.prefixArgs(args)...)
+}
+
+func (p *prefixLogger) Failf(format string, args ...interface{}) {
+	p.logger.Failf(p.prefix+format, args...)
+}
+
+func (p *prefixLogger) Print(level logrus.Level, args ...interface{}) {
+	p.logger.Print(level, p.prefixArgs(args)...)
+}
+
+func (p *prefixLogger) Printf(level logrus.Level, format string, args ...interface{}) {
+	p.logger.Printf(level, p.prefix+format, args...)
+}
+
+func (p *prefixLogger) With(obj interface{}) *LoggerEntry {
+	return &LoggerEntry{
+		logger: p,
+		context: map[string]interface{}{
+			"context-1": obj,
+		},
+	}
+}
+
+func (p *prefixLogger) WithKey(key string, obj interface{}) *LoggerEntry {
+	return &LoggerEntry{
+		logger: p,
+		context: map[string]interface{}{
+			key: obj,
+		},
+	}
+}
+
+// StartWait prints the wait message once, because a loading text can't be shown for several tasks at once
+func (p *prefixLogger) StartWait(message string) {
+	p.logger.Info(p.prefix + message)
+}
+
+// StopWait is a no-op, see StartWait
+func (p *prefixLogger) StopWait() {}
+
+// Write buffers the message and writes every complete line with the prefix. Progress output
+// that overwrites the line with carriage returns is reduced to the latest state of the line
+func (p *prefixLogger) Write(message []byte) (int, error) {
+	p.writeMutex.Lock()
+	defer p.writeMutex.Unlock()
+
+	p.buffer.Write(message)
+
+	for {
+		line, err := p.buffer.ReadString('\n')
+		if err != nil {
+			// Keep the incomplete line for the next write
+			p.buffer.Reset()
+			p.buffer.WriteString(line)
+
+			return len(message), nil
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+		if index := strings.LastIndex(line, "\r"); index != -1 {
+			line = line[index+1:]
+		}
+
+		_, err = p.logger.Write([]byte(p.prefix + line + "\n"))
+		if err != nil {
+			return 0, err
+		}
+	}
+}
+
+func (p *prefixLogger) SetLevel(level logrus.Level) {
+	p.logger.SetLevel(level)
+}
+
+func (p *prefixLogger) printWithContext(fnType logFunctionType, context map[string]interface{}, args ...interface{}) {
+	p.logger.printWithContext(fnType, context, p.prefixArgs(args)...)
+}
+
+func (p *prefixLogger) printWithContextf(fnType logFunctionType, context map[string]interface{}, format string, args ...interface{}) {
+	p.logger.printWithContextf(fnType, context, p.prefix+format, args...)
+}
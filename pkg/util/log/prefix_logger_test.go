@@ -0,0 +1,40 @@
+package log
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestPrefixLoggerWrite(t *testing.T) {
+	output := &bytes.Buffer{}
+	logger := &fileLogger{
+		logger: logrus.New(),
+	}
+	logger.logger.Out = output
+
+	prefixLogger := NewPrefixLogger("[api] ", logger)
+
+	writes := []string{
+		"Step 1/2 : FROM alpine\nStep 2/2",
+		" : RUN make\n",
+		"Sending context 1MB\rSending context 2MB\rSending context 3MB\n",
+		"incomplete",
+	}
+
+	for _, write := range writes {
+		n, err := prefixLogger.Write([]byte(write))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != len(write) {
+			t.Fatalf("Expected %d bytes written, got %d", len(write), n)
+		}
+	}
+
+	expected := "[api] Step 1/2 : FROM alpine\n[api] Step 2/2 : RUN make\n[api] Sending context 3MB\n"
+	if output.String() != expected {
+		t.Fatalf("Expected output %q, got %q", expected, output.String())
+	}
+}
@@ -159,7 +159,7 @@ func (s *stdoutLogger) StartWait(message string) {
 
 	s.loadingText = &loadingText{
 		Message: message,
-		Stream:  os.Stdout,
+		Stream:  fnTypeInformationMap[infoFn].stream,
 	}
 
 	s.loadingText.Start()